@@ -1,6 +1,6 @@
 void main(void) {
 	if( gl_FrontFacing ) {
-		gl_FragColor = vec4(1.0f);
+		gl_FragColor = vec4(1.0);
 	}else{
 		discard;
 	}
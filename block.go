@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// BlockDef describes the properties shared by all blocks with a given Id.
+type BlockDef struct {
+	Id          uint
+	Name        string
+	Solid       bool    // blocks movement and collides
+	Density     float64 // mass of a single voxel
+	Hardness    float64 // effort needed to mine the block
+	Transparent bool    // faces behind the block remain visible
+	Texture     uint    // colour/texture index used when rendering
+	Yield       string  // resource produced when mined, empty for none
+	YieldCount  uint    // amount of Yield produced when mined
+}
+
+// emptyDef is the definition of empty space, Id 0.
+var emptyDef = BlockDef{Name: "empty", Transparent: true}
+
+// BlockRegistry maps block Ids to their definitions.
+type BlockRegistry struct {
+	defs  map[uint]BlockDef
+	names map[string]uint
+}
+
+// DefaultBlocks is the registry consulted by frames that do not have
+// their own.
+var DefaultBlocks = NewBlockRegistry()
+
+// NewBlockRegistry creates a registry containing only empty space.
+func NewBlockRegistry() *BlockRegistry {
+	return &BlockRegistry{
+		map[uint]BlockDef{0: emptyDef},
+		map[string]uint{emptyDef.Name: 0},
+	}
+}
+
+// Register adds a block definition to the registry. Ids and names must be
+// unique, and Id 0 is reserved for empty space.
+func (r *BlockRegistry) Register(def BlockDef) error {
+	if _, ok := r.defs[def.Id]; ok {
+		return fmt.Errorf("block Id %d already registered", def.Id)
+	}
+	if def.Name == "" {
+		return fmt.Errorf("block Id %d has no name", def.Id)
+	}
+	if _, ok := r.names[def.Name]; ok {
+		return fmt.Errorf("block name %q already registered", def.Name)
+	}
+	r.defs[def.Id] = def
+	r.names[def.Name] = def.Id
+	return nil
+}
+
+// Def returns the definition of the block Id. Unregistered Ids are treated
+// as plain solid, opaque blocks of density one.
+func (r *BlockRegistry) Def(id uint) BlockDef {
+	if def, ok := r.defs[id]; ok {
+		return def
+	}
+	return BlockDef{
+		Id:      id,
+		Name:    fmt.Sprintf("unknown %d", id),
+		Solid:   true,
+		Density: 1,
+	}
+}
+
+// ByName returns the definition of the block with the given name.
+func (r *BlockRegistry) ByName(name string) (def BlockDef, ok bool) {
+	id, ok := r.names[name]
+	if ok {
+		def = r.defs[id]
+	}
+	return
+}
+
+// blockDefFile is the representation of a BlockDef in a data file, where
+// blocks are solid with a density of one unless stated otherwise.
+type blockDefFile struct {
+	Id          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Solid       *bool    `json:"solid"`
+	Density     *float64 `json:"density"`
+	Hardness    float64  `json:"hardness"`
+	Transparent bool     `json:"transparent"`
+	Texture     uint     `json:"texture"`
+	Yield       string   `json:"yield"`
+	YieldCount  uint     `json:"yield_count"`
+}
+
+// Load reads a JSON array of block definitions from rd and registers them.
+func (r *BlockRegistry) Load(rd io.Reader) error {
+	var defs []blockDefFile
+	if err := json.NewDecoder(rd).Decode(&defs); err != nil {
+		return fmt.Errorf("reading block definitions: %w", err)
+	}
+
+	for _, d := range defs {
+		def := BlockDef{
+			Id:          d.Id,
+			Name:        d.Name,
+			Solid:       true,
+			Density:     1,
+			Hardness:    d.Hardness,
+			Transparent: d.Transparent,
+			Texture:     d.Texture,
+			Yield:       d.Yield,
+			YieldCount:  d.YieldCount,
+		}
+		if d.Solid != nil {
+			def.Solid = *d.Solid
+		}
+		if d.Density != nil {
+			def.Density = *d.Density
+		}
+		if def.Density < 0 || def.Hardness < 0 {
+			return fmt.Errorf("block %q has negative density or hardness", def.Name)
+		}
+		if def.Yield == "" && def.YieldCount != 0 {
+			return fmt.Errorf("block %q has a yield count but no yield", def.Name)
+		}
+		if err := r.Register(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFile reads block definitions from the named JSON file.
+func (r *BlockRegistry) LoadFile(name string) error {
+	return loadFile(name, r.Load)
+}
+
+// loadFile opens the named file and passes it to load, which reads a data
+// file such as block definitions.
+func loadFile(name string, load func(io.Reader) error) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return load(file)
+}
+
+// registry returns the block registry used by the frame.
+func (f *Frame) registry() *BlockRegistry {
+	if f.Registry != nil {
+		return f.Registry
+	}
+	return DefaultBlocks
+}
+
+// Def returns the definition of the Block at local voxel coordinates
+// (x, y, z).
+func (f *Frame) Def(x, y, z int) BlockDef {
+	return f.registry().Def(f.Block(x, y, z).Id)
+}
+
+// IsSolid returns true if the Block at local voxel coordinates (x, y, z)
+// is solid.
+func (f *Frame) IsSolid(x, y, z int) bool {
+	return f.Def(x, y, z).Solid
+}
+
+// IsTransparent returns true if faces behind the Block at local voxel
+// coordinates (x, y, z) are visible.
+func (f *Frame) IsTransparent(x, y, z int) bool {
+	return f.Def(x, y, z).Transparent
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlockRegistry(t *testing.T) {
+	r := NewBlockRegistry()
+
+	if def := r.Def(0); def.Solid || !def.Transparent || def.Density != 0 {
+		t.Error("NewBlockRegistry did not define empty space")
+	}
+	if def := r.Def(42); !def.Solid || def.Transparent || def.Density != 1 {
+		t.Error("Def did not return solid default for unregistered Id")
+	}
+
+	if err := r.Register(BlockDef{Id: 1, Name: "rock", Solid: true, Density: 3}); err != nil {
+		t.Error("Register returned error: " + err.Error())
+	}
+	if def := r.Def(1); def.Name != "rock" || def.Density != 3 {
+		t.Error("Def did not return registered definition")
+	}
+	if def, ok := r.ByName("rock"); !ok || def.Id != 1 {
+		t.Error("ByName did not return registered definition")
+	}
+	if _, ok := r.ByName("cheese"); ok {
+		t.Error("ByName returned unregistered definition")
+	}
+
+	if r.Register(BlockDef{Id: 1, Name: "other"}) == nil {
+		t.Error("Register accepted duplicate Id")
+	}
+	if r.Register(BlockDef{Id: 0, Name: "air"}) == nil {
+		t.Error("Register accepted Id 0")
+	}
+	if r.Register(BlockDef{Id: 2, Name: "rock"}) == nil {
+		t.Error("Register accepted duplicate name")
+	}
+	if r.Register(BlockDef{Id: 2}) == nil {
+		t.Error("Register accepted empty name")
+	}
+}
+
+func TestBlockRegistryLoad(t *testing.T) {
+	r := NewBlockRegistry()
+	err := r.Load(strings.NewReader(`[
+		{"id": 1, "name": "rock"},
+		{"id": 2, "name": "gas", "solid": false, "density": 0, "transparent": true},
+		{"id": 3, "name": "iron ore", "density": 5, "hardness": 4, "texture": 7, "yield": "iron", "yield_count": 2}
+	]`))
+	if err != nil {
+		t.Fatal("Load returned error: " + err.Error())
+	}
+
+	if def := r.Def(1); !def.Solid || def.Density != 1 || def.Transparent {
+		t.Error("Load did not apply defaults")
+	}
+	if def := r.Def(2); def.Solid || def.Density != 0 || !def.Transparent {
+		t.Error("Load did not override defaults")
+	}
+	expected := BlockDef{3, "iron ore", true, 5, 4, false, 7, "iron", 2}
+	if def := r.Def(3); def != expected {
+		t.Errorf("Load returned %+v, expected %+v", def, expected)
+	}
+
+	bad := []string{
+		`{"id": 1}`,
+		`[{"id": 4, "name": "rock"}]`,
+		`[{"id": 5, "name": "x", "density": -1}]`,
+		`[{"id": 5, "name": "x", "yield_count": 1}]`,
+		`[{"id": 5, "name": "x"`,
+	}
+	for _, s := range bad {
+		if r.Load(strings.NewReader(s)) == nil {
+			t.Error("Load accepted " + s)
+		}
+	}
+}
+
+func TestBlockRegistryLoadFile(t *testing.T) {
+	r := NewBlockRegistry()
+	if err := r.LoadFile("blocks.json"); err != nil {
+		t.Fatal("LoadFile returned error for blocks.json: " + err.Error())
+	}
+	if _, ok := r.ByName("rock"); !ok {
+		t.Error("blocks.json does not define rock")
+	}
+}
+
+func TestFrameRegistry(t *testing.T) {
+	f := NewFrame()
+	f.Registry = NewBlockRegistry()
+	f.Registry.Register(BlockDef{Id: 1, Name: "rock", Solid: true, Density: 1})
+	f.Registry.Register(BlockDef{Id: 2, Name: "gas", Transparent: true})
+
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	f.SetBlock(-1, 0, 0, Block{2, 0})
+
+	if !f.IsSolid(0, 0, 0) || f.IsTransparent(0, 0, 0) {
+		t.Error("Frame did not consult registry for rock")
+	}
+	if f.IsSolid(-1, 0, 0) || !f.IsTransparent(-1, 0, 0) {
+		t.Error("Frame did not consult registry for gas")
+	}
+	if f.IsSolid(1, 0, 0) || !f.IsTransparent(1, 0, 0) {
+		t.Error("Frame did not treat empty space as empty")
+	}
+	if f.Def(0, 0, 0).Name != "rock" {
+		t.Error("Def did not return definition of block")
+	}
+
+	f.Registry = nil
+	if !f.IsSolid(-1, 0, 0) {
+		t.Error("Frame did not fall back to DefaultBlocks")
+	}
+}
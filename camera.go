@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+)
+
+// Controls is the movement requested of a Camera for one update, so that
+// the camera does not depend on where the input comes from.
+type Controls struct {
+	Forward, Back, Left, Right, Up, Down bool
+	LookX, LookY                         float64 // mouse movement since the last update
+}
+
+// Camera is a first-person camera which looks around by yaw and pitch and
+// moves relative to the direction it is facing. Angles are in radians and
+// distances in voxels.
+type Camera struct {
+	Transform   *SQT    // position and orientation in world coordinates
+	Yaw, Pitch  float64 // yaw about the y axis, pitch about the camera's x axis
+	MaxPitch    float64 // pitch is clamped to [-MaxPitch, MaxPitch]
+	Speed       float64 // movement per second
+	Sensitivity float64 // rotation per unit of mouse movement
+	Fov         float64 // vertical field of view
+	Aspect      float64 // width of the view divided by its height
+	Near, Far   float64 // distances of the clipping planes
+}
+
+// NewCamera creates a camera at the origin looking down the -z axis.
+func NewCamera(aspect float64) *Camera {
+	return &Camera{
+		Transform:   NewSQT(),
+		MaxPitch:    math.Pi/2 - 0.01,
+		Speed:       5,
+		Sensitivity: 0.005,
+		Fov:         math.Pi / 3,
+		Aspect:      aspect,
+		Near:        0.1,
+		Far:         1000,
+	}
+}
+
+// Look turns the camera by yaw and pitch, clamping the pitch so that the
+// camera never turns upside down.
+func (c *Camera) Look(yaw, pitch float64) {
+	c.Yaw = math.Remainder(c.Yaw+yaw, 2*math.Pi)
+	c.Pitch = math.Max(-c.MaxPitch, math.Min(c.MaxPitch, c.Pitch+pitch))
+	c.Transform.SetRotation(QuatEuler(c.Yaw, c.Pitch, 0))
+}
+
+// Forward returns the direction the camera is looking in.
+func (c *Camera) Forward() Vec3 {
+	return c.Transform.Rotation().Rotate(Vec3{0, 0, -1})
+}
+
+// Update applies the controls to the camera over dt seconds. Moving the
+// mouse right or down turns the camera right or down. Movement forwards
+// and sideways stays horizontal whatever the pitch, and moving diagonally
+// is no faster than moving straight.
+func (c *Camera) Update(ctl Controls, dt float64) {
+	c.Look(-ctl.LookX*c.Sensitivity, -ctl.LookY*c.Sensitivity)
+
+	var move Vec3
+	if ctl.Forward {
+		move.Z--
+	}
+	if ctl.Back {
+		move.Z++
+	}
+	if ctl.Left {
+		move.X--
+	}
+	if ctl.Right {
+		move.X++
+	}
+	if ctl.Up {
+		move.Y++
+	}
+	if ctl.Down {
+		move.Y--
+	}
+	move = QuatAxisAngle(c.Yaw, Vec3{0, 1, 0}).Rotate(move.Normalise())
+	c.Transform.Translate(move.Mul(c.Speed * dt))
+}
+
+// View returns the matrix transforming world coordinates into the
+// coordinates of the camera.
+func (c *Camera) View() (m Matrix4) {
+	m.LoadSQT(c.Transform.Inverse())
+	return
+}
+
+// Projection returns the perspective projection matrix of the camera.
+func (c *Camera) Projection() (m Matrix4) {
+	m.LoadPerspective(float32(c.Fov), float32(c.Aspect), float32(c.Near), float32(c.Far))
+	return
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCameraLook(t *testing.T) {
+	c := NewCamera(1)
+	checkPoint(t, "Forward", c.Forward(), Vec3{0, 0, -1})
+
+	// Moving the mouse right turns right
+	c.Update(Controls{LookX: math.Pi / 2 / c.Sensitivity}, 0)
+	checkVector(t, "Forward after turning right", c.Forward(), Vec3{1, 0, 0})
+
+	c.Look(-math.Pi/2, math.Pi/2)
+	if c.Pitch != c.MaxPitch {
+		t.Errorf("Look set pitch to %v, expected clamp to %v", c.Pitch, c.MaxPitch)
+	}
+	c.Look(0, -10)
+	if c.Pitch != -c.MaxPitch {
+		t.Errorf("Look set pitch to %v, expected clamp to %v", c.Pitch, -c.MaxPitch)
+	}
+	if f := c.Forward(); f.Y > -0.99 {
+		t.Error("Forward did not look down", f)
+	}
+
+	// Yaw wraps rather than growing without bound
+	for i := 0; i < 10; i++ {
+		c.Look(2, 0)
+	}
+	if c.Yaw < -math.Pi || c.Yaw > math.Pi {
+		t.Error("Look did not wrap yaw", c.Yaw)
+	}
+}
+
+func TestCameraMove(t *testing.T) {
+	c := NewCamera(1)
+	c.Speed = 2
+	c.Update(Controls{Forward: true}, 0.5)
+	checkVector(t, "Moving forward", c.Transform.Translation(), Vec3{0, 0, -1})
+
+	// Movement is relative to the facing, but stays horizontal
+	c = NewCamera(1)
+	c.Look(math.Pi/2, 1)
+	c.Update(Controls{Forward: true}, 1)
+	checkVector(t, "Moving forward after turning", c.Transform.Translation(), Vec3{-c.Speed, 0, 0})
+	c.Update(Controls{Forward: true, Back: true}, 1)
+	checkVector(t, "Moving forward and back", c.Transform.Translation(), Vec3{-c.Speed, 0, 0})
+
+	c = NewCamera(1)
+	c.Update(Controls{Right: true, Up: true}, 1)
+	d := c.Speed / math.Sqrt2
+	checkVector(t, "Moving diagonally", c.Transform.Translation(), Vec3{d, d, 0})
+}
+
+func TestCameraView(t *testing.T) {
+	c := NewCamera(1)
+	c.Transform.SetTranslation(Vec3{1, 2, 3})
+	c.Look(math.Pi/2, 0)
+
+	v := c.View()
+	checkPoint32(t, "View of camera position", v.TransformPoint(Vec3{1, 2, 3}), Vec3{})
+	checkPoint32(t, "View of point in front", v.TransformPoint(Vec3{1, 2, 3}.Add(c.Forward().Mul(4))), Vec3{0, 0, -4})
+
+	p := c.Projection()
+	vp := p.Multiply(&v)
+	ahead := vp.TransformPoint(Vec3{1, 2, 3}.Add(c.Forward().Mul(4)))
+	if math.Abs(ahead.X) > 1e-6 || math.Abs(ahead.Y) > 1e-6 || ahead.Z < -1 || ahead.Z > 1 {
+		t.Error("Projection did not map point in front into view", ahead)
+	}
+	behind := vp.Transform([4]float32{1, 2, 3 + 4, 1})
+	if behind[3] > 0 {
+		t.Error("Projection did not put point behind camera behind", behind)
+	}
+}
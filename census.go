@@ -0,0 +1,101 @@
+package main
+
+// census counts the non-empty blocks of each Id in each chunk of a frame,
+// and in the whole frame, so that it can be updated incrementally as
+// blocks change.
+type census struct {
+	chunks map[pos]map[uint]int
+	totals map[uint]int
+}
+
+// update replaces Block old with Block b in the chunk at p.
+func (c *census) update(p pos, old, b Block) {
+	if old == b {
+		return
+	}
+	if c.chunks == nil {
+		c.chunks = make(map[pos]map[uint]int)
+		c.totals = make(map[uint]int)
+	}
+	if !old.IsEmpty() {
+		counts := c.chunks[p]
+		if counts[old.Id]--; counts[old.Id] == 0 {
+			delete(counts, old.Id)
+			if len(counts) == 0 {
+				delete(c.chunks, p)
+			}
+		}
+		if c.totals[old.Id]--; c.totals[old.Id] == 0 {
+			delete(c.totals, old.Id)
+		}
+	}
+	if !b.IsEmpty() {
+		counts, ok := c.chunks[p]
+		if !ok {
+			counts = make(map[uint]int)
+			c.chunks[p] = counts
+		}
+		counts[b.Id]++
+		c.totals[b.Id]++
+	}
+}
+
+// recount recalculates the frame's census from scratch, for when chunks
+// are filled without SetBlock.
+func (f *Frame) recount() {
+	f.census = census{}
+	for p, c := range f.chunks {
+		for i := range c {
+			for j := range c[i] {
+				for _, b := range c[i][j] {
+					f.census.update(p, Block{}, b)
+				}
+			}
+		}
+	}
+}
+
+// ChunkCensus returns the number of blocks of each Id in the chunk at
+// chunk coordinates (cx, cy, cz). Empty space is not counted.
+func (f *Frame) ChunkCensus(cx, cy, cz int) map[uint]int {
+	counts := make(map[uint]int)
+	for id, n := range f.census.chunks[pos{cx, cy, cz}] {
+		counts[id] = n
+	}
+	return counts
+}
+
+// Count returns the number of blocks with the given Id in the frame.
+func (f *Frame) Count(id uint) int {
+	return f.census.totals[id]
+}
+
+// Resources returns the total amount of each resource the frame's blocks
+// would yield if mined, according to its block registry.
+func (f *Frame) Resources() map[string]uint {
+	return f.resources(f.census.totals)
+}
+
+// ChunkResources returns the amount of each resource the blocks of the
+// chunk at chunk coordinates (cx, cy, cz) would yield if mined.
+func (f *Frame) ChunkResources(cx, cy, cz int) map[string]uint {
+	return f.resources(f.census.chunks[pos{cx, cy, cz}])
+}
+
+// resources converts counts of block Ids into amounts of resources.
+func (f *Frame) resources(counts map[uint]int) map[string]uint {
+	reg := f.registry()
+	res := make(map[string]uint)
+	for id, n := range counts {
+		if def := reg.Def(id); def.Yield != "" {
+			res[def.Yield] += def.YieldCount * uint(n)
+		}
+	}
+	return res
+}
+
+// Resource returns the total amount of the named resource the frame's
+// blocks would yield if mined.
+func (f *Frame) Resource(name string) uint {
+	return f.Resources()[name]
+}
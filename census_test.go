@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// bruteCensus counts the blocks of each Id in f by walking every voxel.
+func bruteCensus(f *Frame) map[uint]int {
+	counts := make(map[uint]int)
+	for _, c := range f.chunks {
+		for i := range c {
+			for j := range c[i] {
+				for _, b := range c[i][j] {
+					if !b.IsEmpty() {
+						counts[b.Id]++
+					}
+				}
+			}
+		}
+	}
+	return counts
+}
+
+func checkCensus(t *testing.T, name string, f *Frame) {
+	expected := bruteCensus(f)
+	if len(expected) != len(f.census.totals) {
+		t.Errorf("%s: census has %d Ids, expected %d", name, len(f.census.totals), len(expected))
+	}
+	for id, n := range expected {
+		if f.Count(id) != n {
+			t.Errorf("%s: Count(%d) returned %d, expected %d", name, id, f.Count(id), n)
+		}
+	}
+}
+
+func TestCensus(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	f.SetBlock(1, 0, 0, Block{1, 0})
+	f.SetBlock(2, 0, 0, Block{3, 0})
+	f.SetBlock(20, 0, 0, Block{3, 0})
+	checkCensus(t, "after setting", f)
+
+	if c := f.ChunkCensus(0, 0, 0); len(c) != 2 || c[1] != 2 || c[3] != 1 {
+		t.Error("ChunkCensus returned wrong counts", c)
+	}
+	if c := f.ChunkCensus(1, 0, 0); len(c) != 1 || c[3] != 1 {
+		t.Error("ChunkCensus returned wrong counts for second chunk", c)
+	}
+
+	// The returned counts are a copy
+	f.ChunkCensus(0, 0, 0)[1] = 100
+	if f.ChunkCensus(0, 0, 0)[1] != 2 {
+		t.Error("ChunkCensus returned census by reference")
+	}
+
+	// Replacing and removing blocks
+	f.SetBlock(1, 0, 0, Block{3, 0})
+	f.SetBlock(20, 0, 0, Block{})
+	checkCensus(t, "after replacing", f)
+	if len(f.ChunkCensus(1, 0, 0)) != 0 {
+		t.Error("ChunkCensus counted blocks in deleted chunk")
+	}
+	if _, ok := f.census.chunks[pos{1, 0, 0}]; ok {
+		t.Error("census kept counts for deleted chunk")
+	}
+}
+
+func TestCensusReadFrame(t *testing.T) {
+	f := NewFrame()
+	for i := 0; i < 40; i++ {
+		f.SetBlock(i, i%3, -i, Block{uint(1 + i%4), 0})
+	}
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	g, err := ReadFrame(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCensus(t, "read frame", g)
+	for id := uint(1); id <= 4; id++ {
+		if g.Count(id) != f.Count(id) {
+			t.Errorf("ReadFrame gave Count(%d) = %d, expected %d", id, g.Count(id), f.Count(id))
+		}
+	}
+}
+
+func TestResources(t *testing.T) {
+	f := NewFrame()
+	f.Registry = NewBlockRegistry()
+	f.Registry.Register(BlockDef{Id: 1, Name: "rock", Solid: true, Yield: "rock", YieldCount: 1})
+	f.Registry.Register(BlockDef{Id: 3, Name: "iron ore", Solid: true, Yield: "iron", YieldCount: 1})
+	f.Registry.Register(BlockDef{Id: 5, Name: "gas"})
+	f.Registry.Register(BlockDef{Id: 7, Name: "rich iron ore", Solid: true, Yield: "iron", YieldCount: 3})
+
+	f.SetBlock(0, 0, 0, Block{3, 0})
+	f.SetBlock(1, 0, 0, Block{3, 0})
+	f.SetBlock(2, 0, 0, Block{7, 0})
+	f.SetBlock(3, 0, 0, Block{1, 0})
+	f.SetBlock(4, 0, 0, Block{5, 0})
+
+	res := f.Resources()
+	if len(res) != 2 || res["iron"] != 5 || res["rock"] != 1 {
+		t.Error("Resources returned wrong amounts", res)
+	}
+	if f.Resource("iron") != 5 || f.Resource("copper") != 0 {
+		t.Error("Resource returned wrong amount")
+	}
+
+	f.SetBlock(20, 0, 0, Block{7, 0})
+	if res := f.ChunkResources(0, 0, 0); len(res) != 2 || res["iron"] != 5 || res["rock"] != 1 {
+		t.Error("ChunkResources returned wrong amounts", res)
+	}
+	if res := f.ChunkResources(1, 0, 0); len(res) != 1 || res["iron"] != 3 {
+		t.Error("ChunkResources returned wrong amounts for second chunk", res)
+	}
+	if res := f.ChunkResources(5, 0, 0); len(res) != 0 {
+		t.Error("ChunkResources returned resources for empty chunk", res)
+	}
+}
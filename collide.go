@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+)
+
+// Contact describes a point where the voxels of two frames overlap. All
+// values are in world space.
+type Contact struct {
+	Point  Vec3    // contact point
+	Normal Vec3    // unit normal along which to move the first frame to separate them
+	Depth  float64 // penetration depth along the normal
+}
+
+// Collide returns the contacts between the solid voxels of frames a and b.
+//
+// The broad phase compares the world space bounding boxes of the chunks of
+// both frames. In the narrow phase, each solid voxel of b in a chunk that
+// overlaps a is mapped into the local coordinates of a through the
+// relative transform, approximated by its inscribed sphere, and tested
+// against the solid voxels of a's grid. Voxels which only touch do not
+// collide. The sphere leaves out the edges and corners of b's voxels,
+// so voxels which overlap only there give no contact. Contacts are
+// returned in the same order every time.
+func Collide(a, b *Frame) []Contact {
+	aw, bw := a.world(), b.world()
+
+	var boxes [][2][3]float64
+	for _, p := range a.chunkPositions() {
+		boxes = append(boxes, chunkBounds(p, aw))
+	}
+
+	rel := aw.Inverse().Compose(bw)
+	r := 0.5 * math.Abs(rel.scale)
+	areg, breg := a.registry(), b.registry()
+
+	var contacts []Contact
+	for _, p := range b.chunkPositions() {
+		c := b.chunks[p]
+		bb := chunkBounds(p, bw)
+		overlap := false
+		for _, ab := range boxes {
+			if boxesOverlap(ab, bb) {
+				overlap = true
+				break
+			}
+		}
+		if !overlap {
+			continue
+		}
+
+		for i := 0; i < ncx; i++ {
+			for j := 0; j < ncy; j++ {
+				for k := 0; k < ncz; k++ {
+					if c[i][j][k].IsEmpty() || !breg.Def(c[i][j][k].Id).Solid {
+						continue
+					}
+					centre := rel.TransformAbs(Vec3{
+						float64(p.x*ncx+i) + 0.5,
+						float64(p.y*ncy+j) + 0.5,
+						float64(p.z*ncz+k) + 0.5,
+					})
+					contacts = collideVoxel(contacts, a, areg, aw, centre, r)
+				}
+			}
+		}
+	}
+
+	return contacts
+}
+
+// collideVoxel appends the contacts between the sphere of radius r centred
+// on the point centre, in the local coordinates of frame f, and the solid
+// voxels of f.
+func collideVoxel(contacts []Contact, f *Frame, reg *BlockRegistry, w *SQT, centre Vec3, r float64) []Contact {
+	x0, y0, z0 := int(math.Floor(centre.X-r)), int(math.Floor(centre.Y-r)), int(math.Floor(centre.Z-r))
+	x1, y1, z1 := int(math.Floor(centre.X+r)), int(math.Floor(centre.Y+r)), int(math.Floor(centre.Z+r))
+	c := [3]float64{centre.X, centre.Y, centre.Z}
+
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			for z := z0; z <= z1; z++ {
+				b := f.Block(x, y, z)
+				if b.IsEmpty() || !reg.Def(b.Id).Solid {
+					continue
+				}
+
+				// Closest point of the voxel to the centre
+				min := [3]float64{float64(x), float64(y), float64(z)}
+				var q, n [3]float64
+				d2 := 0.0
+				for i := 0; i < 3; i++ {
+					q[i] = math.Max(min[i], math.Min(min[i]+1, c[i]))
+					n[i] = q[i] - c[i]
+					d2 += n[i] * n[i]
+				}
+
+				var depth float64
+				if d2 > 0 {
+					d := math.Sqrt(d2)
+					if d >= r {
+						continue
+					}
+					depth = r - d
+					for i := range n {
+						n[i] /= d
+					}
+				} else {
+					// The centre is inside the voxel, so push out through
+					// the nearest face
+					best := math.Inf(1)
+					for i := 0; i < 3; i++ {
+						if e := c[i] - min[i]; e < best {
+							best, n = e, [3]float64{}
+							n[i] = 1
+						}
+						if e := min[i] + 1 - c[i]; e < best {
+							best, n = e, [3]float64{}
+							n[i] = -1
+						}
+					}
+					depth = r + best
+				}
+
+				contacts = append(contacts, Contact{
+					Point:  w.TransformAbs(Vec3{q[0], q[1], q[2]}),
+					Normal: w.TransformRel(Vec3{n[0], n[1], n[2]}).Normalise(),
+					Depth:  depth * math.Abs(w.scale),
+				})
+			}
+		}
+	}
+
+	return contacts
+}
+
+// chunkBounds returns the bounding box of the chunk at chunk position p
+// after transformation by s.
+func chunkBounds(p pos, s *SQT) (box [2][3]float64) {
+	box[0] = [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	box[1] = [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for i := 0; i < 8; i++ {
+		t := s.TransformAbs(Vec3{
+			float64((p.x + (i & 1)) * ncx),
+			float64((p.y + (i >> 1 & 1)) * ncy),
+			float64((p.z + (i >> 2 & 1)) * ncz),
+		})
+		v := [3]float64{t.X, t.Y, t.Z}
+		for j := 0; j < 3; j++ {
+			box[0][j] = math.Min(box[0][j], v[j])
+			box[1][j] = math.Max(box[1][j], v[j])
+		}
+	}
+	return
+}
+
+// boxesOverlap returns true if the axis-aligned boxes a and b intersect.
+func boxesOverlap(a, b [2][3]float64) bool {
+	for i := 0; i < 3; i++ {
+		if a[1][i] < b[0][i] || b[1][i] < a[0][i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCollideSeparate(t *testing.T) {
+	a := NewFrame()
+	a.SetBlock(0, 0, 0, Block{1, 0})
+	b := NewFrame()
+	b.SetBlock(0, 0, 0, Block{1, 0})
+
+	b.Transform.SetTranslation(Vec3{100, 0, 0})
+	if c := Collide(a, b); len(c) != 0 {
+		t.Error("Collide returned contacts for distant frames")
+	}
+
+	// Face to face contact is not a collision
+	b.Transform.SetTranslation(Vec3{1, 0, 0})
+	if c := Collide(a, b); len(c) != 0 {
+		t.Error("Collide returned contacts for touching voxels")
+	}
+
+	// Nor is overlap with a non-solid block
+	b.Transform.SetTranslation(Vec3{0.5, 0, 0})
+	b.Registry = NewBlockRegistry()
+	b.Registry.Register(BlockDef{Id: 1, Name: "gas"})
+	if c := Collide(a, b); len(c) != 0 {
+		t.Error("Collide returned contacts for non-solid voxel")
+	}
+}
+
+func TestCollideOverlap(t *testing.T) {
+	a := NewFrame()
+	a.SetBlock(-1, -1, -1, Block{1, 0})
+	b := NewFrame()
+	b.SetBlock(0, 0, 0, Block{1, 0})
+	b.Transform.SetTranslation(Vec3{-0.5, -1, -1})
+
+	c := Collide(a, b)
+	if len(c) != 1 {
+		t.Fatalf("Collide returned %d contacts, expected 1", len(c))
+	}
+	checkContact(t, "overlapping voxels", c[0], Vec3{0, -0.5, -0.5}, Vec3{-1, 0, 0}, 0.5)
+}
+
+func TestCollideTransformed(t *testing.T) {
+	a := NewFrame()
+	a.SetBlock(0, 0, 0, Block{1, 0})
+
+	// b's voxel covers world [-2, 0] x [0, 2] x [0, 2] before translation,
+	// centred at (1.5, 0.5, 0.5) after it.
+	b := NewFrame()
+	b.Transform.SetScale(2)
+	b.Transform.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{0, 0, 1}))
+	b.Transform.SetTranslation(Vec3{2.5, -0.5, -0.5})
+	b.SetBlock(0, 0, 0, Block{1, 0})
+
+	c := Collide(a, b)
+	if len(c) != 1 {
+		t.Fatalf("Collide returned %d contacts, expected 1", len(c))
+	}
+	checkContact(t, "scaled and rotated b", c[0], Vec3{1, 0.5, 0.5}, Vec3{-1, 0, 0}, 0.5)
+
+	c = Collide(b, a)
+	if len(c) != 1 {
+		t.Fatalf("Collide returned %d contacts, expected 1", len(c))
+	}
+	checkContact(t, "scaled and rotated a", c[0], Vec3{0.5, 0.5, 0.5}, Vec3{1, 0, 0}, 0.5)
+
+	b.Transform.SetTranslation(Vec3{3.5, -0.5, -0.5})
+	if c := Collide(a, b); len(c) != 0 {
+		t.Error("Collide returned contacts for separated scaled and rotated frames")
+	}
+}
+
+func TestCollideBroadPhase(t *testing.T) {
+	a := NewFrame()
+	b := NewFrame()
+	for x := 0; x < 4*ncx; x++ {
+		a.SetBlock(x, 0, 0, Block{1, 0})
+		b.SetBlock(x, 0, 0, Block{1, 0})
+	}
+	b.Transform.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{0, 0, 1}))
+	b.Transform.SetTranslation(Vec3{2*ncx + 0.5, -ncy, 0})
+
+	// The frames cross at a single voxel of each
+	c := Collide(a, b)
+	if len(c) == 0 {
+		t.Fatal("Collide did not return contacts for crossing frames")
+	}
+	for _, contact := range c {
+		if p := contact.Point; p.X < 2*ncx-1 || p.X > 2*ncx+2 || p.Y < -1 || p.Y > 2 {
+			t.Errorf("Collide returned contact at %v away from crossing", p)
+		}
+	}
+}
+
+func TestCollideOrder(t *testing.T) {
+	a := NewFrame()
+	b := NewFrame()
+	for x := -ncx; x < 2*ncx; x++ {
+		for y := -2; y < 2; y++ {
+			a.SetBlock(x, y, 0, Block{1, 0})
+			b.SetBlock(y, x, 0, Block{1, 0})
+		}
+	}
+	b.Transform.SetRotation(QuatAxisAngle(0.3, Vec3{0, 0, 1}))
+
+	c := Collide(a, b)
+	if len(c) == 0 {
+		t.Fatal("Collide did not return contacts for crossing frames")
+	}
+	for i := 0; i < 10; i++ {
+		if !reflect.DeepEqual(Collide(a, b), c) {
+			t.Fatal("Collide returned contacts in a different order")
+		}
+	}
+}
+
+func TestCollideEdge(t *testing.T) {
+	// The voxels overlap along an edge, outside the inscribed sphere of
+	// b's voxel
+	a := NewFrame()
+	a.SetBlock(0, 0, 0, Block{1, 0})
+	b := NewFrame()
+	b.SetBlock(0, 0, 0, Block{1, 0})
+	b.Transform.SetTranslation(Vec3{0.9, 0.9, 0})
+	if c := Collide(a, b); len(c) != 0 {
+		t.Error("Collide returned contacts for overlap outside the inscribed sphere")
+	}
+}
+
+func checkContact(t *testing.T, desc string, c Contact, point, normal Vec3, depth float64) {
+	if c.Point.Sub(point).Length() > 1e-12 {
+		t.Errorf("Collide returned contact at %v for %s, expected %v", c.Point, desc, point)
+	}
+	if c.Normal.Sub(normal).Length() > 1e-12 {
+		t.Errorf("Collide returned normal %v for %s, expected %v", c.Normal, desc, normal)
+	}
+	if math.Abs(c.Depth-depth) > 1e-12 {
+		t.Errorf("Collide returned depth %v for %s, expected %v", c.Depth, desc, depth)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"sort"
 )
 
 // Block represents the physical state of a single voxel. The zero value
@@ -25,38 +26,212 @@ func (p pos) String() string {
 
 // Frame represents a reference frame, specifying a coordinate system
 // defined by an SQT transformation and storing the voxel data associated
-// with that frame.
+// with that frame. The transformation is relative to the parent frame, or
+// to the world if the frame has no parent.
 type Frame struct {
 	Transform *SQT
+	Registry  *BlockRegistry // block definitions, DefaultBlocks if nil
+	Collector *Inventory     // credited with the yield of mined blocks, if not nil
 	chunks    map[pos]chunk
+
+	// Version of each chunk, changed whenever its mesh might change, and
+	// the last version handed out
+	versions map[pos]uint
+	version  uint
+
+	mass      massProps
+	census    census
+	observers []BlockObserver
+
+	parent   *Frame
+	children []*Frame
+
+	// Cached world transformation, and the local and parent world
+	// transformations it was computed from
+	cached       bool
+	cachedWorld  SQT
+	cachedLocal  SQT
+	cachedParent SQT
 }
 
 func NewFrame() *Frame {
 	return &Frame{
-		NewSQT(),
-		make(map[pos]chunk),
+		Transform: NewSQT(),
+		chunks:    make(map[pos]chunk),
+		versions:  make(map[pos]uint),
+	}
+}
+
+// split divides the voxel coordinate v into the coordinate of the chunk
+// containing it and the offset within that chunk, rounding towards negative
+// infinity so that the offset is always in the range [0, n).
+func split(v, n int) (c, o int) {
+	c, o = v/n, v%n
+	if o < 0 {
+		c--
+		o += n
 	}
+	return
+}
+
+// locate returns the position of the chunk containing the voxel at local
+// coordinates (x, y, z) and the voxel's index within that chunk.
+func locate(x, y, z int) (p pos, i, j, k int) {
+	p.x, i = split(x, ncx)
+	p.y, j = split(y, ncy)
+	p.z, k = split(z, ncz)
+	return
 }
 
 // Block returns the Block at local voxel coordinates (x, y, z)
 func (f *Frame) Block(x, y, z int) Block {
-	p := pos{x / ncx, y / ncy, z / ncz}
+	p, i, j, k := locate(x, y, z)
 	c := f.chunks[p]
-	return c[x%ncx][y%ncy][z%ncz]
+	return c[i][j][k]
 }
 
-// Block changes the Block at local voxel coordinates (x, y, z)
+// SetBlock changes the Block at local voxel coordinates (x, y, z). Setting
+// a block to empty space mines it, crediting its yield to the frame's
+// Collector. The voxel is always cleared, so a yield which does not fit is
+// lost, with the failure recorded in the Collector's ledger; use Mine to
+// leave the block in place instead.
 func (f *Frame) SetBlock(x, y, z int, b Block) {
-	p := pos{x / ncx, y / ncy, z / ncz}
+	if b.IsEmpty() {
+		f.collect(x, y, z)
+	}
+	f.setBlock(x, y, z, b)
+}
+
+// Mine empties the voxel at local voxel coordinates (x, y, z), crediting
+// the yield of its block to the frame's Collector. If the yield does not
+// fit, the block is left in place and the error is returned.
+func (f *Frame) Mine(x, y, z int) error {
+	if err := f.collect(x, y, z); err != nil {
+		return err
+	}
+	f.setBlock(x, y, z, Block{})
+	return nil
+}
+
+// collect credits the frame's Collector, if any, with the yield of the
+// block at local voxel coordinates (x, y, z).
+func (f *Frame) collect(x, y, z int) error {
+	old := f.Block(x, y, z)
+	if f.Collector == nil || old.IsEmpty() {
+		return nil
+	}
+	return f.Collector.collect(f.registry(), x, y, z, old)
+}
+
+// setBlock changes the Block at local voxel coordinates (x, y, z) without
+// mining it.
+func (f *Frame) setBlock(x, y, z int, b Block) {
+	p, i, j, k := locate(x, y, z)
 	c := f.chunks[p]
-	c[x%ncx][y%ncy][z%ncz] = b
+	if c[i][j][k] == b {
+		return
+	}
+	old := c[i][j][k]
+	f.mass.update(f.registry(), x, y, z, old, b)
+	f.census.update(p, old, b)
+	c[i][j][k] = b
 	if b.IsEmpty() && c.isEmpty() {
 		delete(f.chunks, p)
+		delete(f.versions, p)
+		if len(f.chunks) == 0 {
+			// Rounding leaves the running sums slightly off zero
+			f.mass = massProps{}
+		}
 	} else {
 		f.chunks[p] = c
+		f.touch(p)
+	}
+
+	// Faces on the edge of a chunk are culled against its neighbours
+	for d, o := range [3]int{i, j, k} {
+		n := [3]int{p.x, p.y, p.z}
+		switch o {
+		case 0:
+			n[d]--
+		case chunkDims[d] - 1:
+			n[d]++
+		default:
+			continue
+		}
+		q := pos{n[0], n[1], n[2]}
+		if _, ok := f.chunks[q]; ok {
+			f.touch(q)
+		}
+	}
+
+	for _, o := range f.observers {
+		o.BlockChanged(f, x, y, z, old, b)
+	}
+}
+
+// BlockObserver is told about every change to the blocks of the frames it
+// observes.
+type BlockObserver interface {
+	// BlockChanged is called after Block old at local voxel coordinates
+	// (x, y, z) of f has been replaced by Block b.
+	BlockChanged(f *Frame, x, y, z int, old, b Block)
+}
+
+// Observe adds o to the observers of the frame.
+func (f *Frame) Observe(o BlockObserver) {
+	f.observers = append(f.observers, o)
+}
+
+// Unobserve removes o from the observers of the frame.
+func (f *Frame) Unobserve(o BlockObserver) {
+	for i, p := range f.observers {
+		if p == o {
+			f.observers = append(f.observers[:i], f.observers[i+1:]...)
+			return
+		}
 	}
 }
 
+// touch gives the chunk at p a new version.
+func (f *Frame) touch(p pos) {
+	if f.versions == nil {
+		f.versions = make(map[pos]uint)
+	}
+	f.version++
+	f.versions[p] = f.version
+}
+
+// chunkVersion returns the version of the chunk at p, which changes
+// whenever a block in it or on the edge of a neighbouring chunk changes.
+func (f *Frame) chunkVersion(p pos) uint {
+	return f.versions[p]
+}
+
+// chunkPositions returns the positions of the frame's chunks in a fixed
+// order, for when iterating over the chunk map must be deterministic.
+func (f *Frame) chunkPositions() []pos {
+	ps := make([]pos, 0, len(f.chunks))
+	for p := range f.chunks {
+		ps = append(ps, p)
+	}
+	sortPositions(ps)
+	return ps
+}
+
+// sortPositions sorts chunk positions by x, then y, then z.
+func sortPositions(ps []pos) {
+	sort.Slice(ps, func(i, j int) bool {
+		a, b := ps[i], ps[j]
+		if a.x != b.x {
+			return a.x < b.x
+		}
+		if a.y != b.y {
+			return a.y < b.y
+		}
+		return a.z < b.z
+	})
+}
+
 // IsEmpty returns true if the Block represents empty space, and
 // false otherwise.
 func (b Block) IsEmpty() bool {
@@ -31,18 +31,18 @@ func checkFrame(
 	ypx, ypy, ypz float64,
 	zpx, zpy, zpz float64,
 ) bool {
-	xppx, xppy, xppz := s.TransformAbs(1, 0, 0)
-	if math.Abs(xppx-xpx) > 1e-15 || math.Abs(xppy-xpy) > 1e-15 || math.Abs(xppz-xpz) > 1e-15 {
+	xpp := s.TransformAbs(Vec3{1, 0, 0})
+	if math.Abs(xpp.X-xpx) > 1e-15 || math.Abs(xpp.Y-xpy) > 1e-15 || math.Abs(xpp.Z-xpz) > 1e-15 {
 		return false
 	}
 
-	yppx, yppy, yppz := s.TransformAbs(0, 1, 0)
-	if math.Abs(yppx-ypx) > 1e-15 || math.Abs(yppy-ypy) > 1e-15 || math.Abs(yppz-ypz) > 1e-15 {
+	ypp := s.TransformAbs(Vec3{0, 1, 0})
+	if math.Abs(ypp.X-ypx) > 1e-15 || math.Abs(ypp.Y-ypy) > 1e-15 || math.Abs(ypp.Z-ypz) > 1e-15 {
 		return false
 	}
 
-	zppx, zppy, zppz := s.TransformAbs(0, 0, 1)
-	if math.Abs(zppx-zpx) > 1e-15 || math.Abs(zppy-zpy) > 1e-15 || math.Abs(zppz-zpz) > 1e-15 {
+	zpp := s.TransformAbs(Vec3{0, 0, 1})
+	if math.Abs(zpp.X-zpx) > 1e-15 || math.Abs(zpp.Y-zpy) > 1e-15 || math.Abs(zpp.Z-zpz) > 1e-15 {
 		return false
 	}
 
@@ -93,3 +93,167 @@ func TestBlocks(t *testing.T) {
 		}
 	}
 }
+
+func TestLocate(t *testing.T) {
+	cases := []struct {
+		v, c, o int
+	}{
+		{0, 0, 0},
+		{1, 0, 1},
+		{15, 0, 15},
+		{16, 1, 0},
+		{17, 1, 1},
+		{-1, -1, 15},
+		{-15, -1, 1},
+		{-16, -1, 0},
+		{-17, -2, 15},
+		{-32, -2, 0},
+		{-33, -3, 15},
+		{math.MaxInt64, math.MaxInt64 / 16, 15},
+		{math.MinInt64, math.MinInt64 / 16, 0},
+		{math.MinInt64 + 1, math.MinInt64 / 16, 1},
+	}
+
+	for _, c := range cases {
+		p, i, j, k := locate(c.v, c.v, c.v)
+		if p != (pos{c.c, c.c, c.c}) {
+			t.Errorf("locate(%d) returned chunk %s, expected %d", c.v, p, c.c)
+		}
+		if i != c.o || j != c.o || k != c.o {
+			t.Errorf("locate(%d) returned offset (%d, %d, %d), expected %d", c.v, i, j, k, c.o)
+		}
+	}
+}
+
+func TestNegativeBlocks(t *testing.T) {
+	f := NewFrame()
+
+	coords := []int{-33, -32, -17, -16, -15, -1, 0, 1, 15, 16, 17, math.MinInt64, math.MaxInt64}
+	id := uint(1)
+	for _, x := range coords {
+		for _, y := range coords {
+			for _, z := range coords {
+				f.SetBlock(x, y, z, Block{id, 0})
+				id++
+			}
+		}
+	}
+
+	id = 1
+	for _, x := range coords {
+		for _, y := range coords {
+			for _, z := range coords {
+				if b := f.Block(x, y, z); b.Id != id {
+					t.Errorf("Block(%d, %d, %d) returned Id %d, expected %d", x, y, z, b.Id, id)
+				}
+				id++
+			}
+		}
+	}
+
+	if f.Block(-2, -1, -1).Id == f.Block(-1, -1, -1).Id {
+		t.Error("Block did not distinguish neighbouring voxels below zero")
+	}
+
+	for _, x := range coords {
+		for _, y := range coords {
+			for _, z := range coords {
+				f.SetBlock(x, y, z, Block{})
+			}
+		}
+	}
+
+	for pos := range f.chunks {
+		t.Error("SetBlock did not clear chunk at " + pos.String())
+	}
+}
+
+func TestChunkBoundaries(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(-1, 0, 0, Block{1, 0})
+	f.SetBlock(0, 0, 0, Block{2, 0})
+	f.SetBlock(0, -16, 0, Block{3, 0})
+	f.SetBlock(0, 0, -17, Block{4, 0})
+
+	expected := map[pos]uint{
+		pos{-1, 0, 0}: 1,
+		pos{0, 0, 0}:  2,
+		pos{0, -1, 0}: 3,
+		pos{0, 0, -2}: 4,
+	}
+	if len(f.chunks) != len(expected) {
+		t.Errorf("SetBlock stored %d chunks, expected %d", len(f.chunks), len(expected))
+	}
+	for p, id := range expected {
+		c, ok := f.chunks[p]
+		if !ok {
+			t.Error("SetBlock did not store chunk at " + p.String())
+			continue
+		}
+		found := false
+		for _, plane := range c {
+			for _, row := range plane {
+				for _, b := range row {
+					if b.Id == id {
+						found = true
+					}
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Chunk at %s does not contain block %d", p, id)
+		}
+	}
+
+	if c := f.chunks[pos{-1, 0, 0}]; c[15][0][0].Id != 1 {
+		t.Error("SetBlock did not store (-1, 0, 0) at the end of chunk (-1, 0, 0)")
+	}
+	if c := f.chunks[pos{0, -1, 0}]; c[0][0][0].Id != 3 {
+		t.Error("SetBlock did not store (0, -16, 0) at the start of chunk (0, -1, 0)")
+	}
+	if c := f.chunks[pos{0, 0, -2}]; c[0][0][15].Id != 4 {
+		t.Error("SetBlock did not store (0, 0, -17) at the end of chunk (0, 0, -2)")
+	}
+}
+
+// changeLog is a BlockObserver which records the changes it is told about.
+type changeLog struct {
+	changes [][5]uint
+}
+
+func (l *changeLog) BlockChanged(f *Frame, x, y, z int, old, b Block) {
+	if f.Block(x, y, z) != b {
+		panic("BlockChanged called before block changed")
+	}
+	l.changes = append(l.changes, [5]uint{uint(x), uint(y), uint(z), old.Id, b.Id})
+}
+
+func TestObserve(t *testing.T) {
+	f := NewFrame()
+	a, b := &changeLog{}, &changeLog{}
+	f.Observe(a)
+	f.Observe(b)
+
+	f.SetBlock(1, 2, 3, Block{1, 0})
+	f.SetBlock(1, 2, 3, Block{1, 0})
+	f.SetBlock(1, 2, 3, Block{2, 0})
+	expected := [][5]uint{{1, 2, 3, 0, 1}, {1, 2, 3, 1, 2}}
+	if len(a.changes) != len(expected) || a.changes[0] != expected[0] || a.changes[1] != expected[1] {
+		t.Error("observer was told about changes", a.changes)
+	}
+
+	f.Unobserve(a)
+	f.SetBlock(1, 2, 3, Block{})
+	if len(a.changes) != 2 || len(b.changes) != 3 {
+		t.Error("Unobserve did not remove only its observer")
+	}
+
+	// Blocks moved out by Split are changes too
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	f.SetBlock(2, 0, 0, Block{1, 0})
+	f.SetBlock(3, 0, 0, Block{1, 0})
+	f.Split()
+	if last := b.changes[len(b.changes)-1]; last != [5]uint{0, 0, 0, 1, 0} {
+		t.Error("observer was not told about split", last)
+	}
+}
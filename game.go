@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math"
+)
+
+// Game runs the simulation at a fixed tick rate, independent of how often
+// it is rendered. Rendering interpolates between the last two ticks so
+// that motion stays smooth when frames and ticks do not line up.
+type Game struct {
+	Tick     float64 // length of a single tick in seconds
+	MaxSteps int     // most ticks run by one Advance before time is dropped
+	Ticks    int     // number of ticks run so far
+	Done     bool    // the player has asked to quit
+
+	Actions *Actions
+	Camera  *Camera
+	Physics *Physics
+	Frames  []*Frame     // frames drawn by Render
+	Power   []*PowerGrid // power grids shared out every tick
+
+	accumulator float64
+	prevCamera  SQT
+	prev        map[*Frame]SQT
+}
+
+// NewGame creates a game with no frames which ticks by tick seconds and
+// reads the player's actions from a.
+func NewGame(tick float64, a *Actions) *Game {
+	g := &Game{
+		Tick:     tick,
+		MaxSteps: 5,
+		Actions:  a,
+		Camera:   NewCamera(4.0 / 3.0),
+		Physics:  NewPhysics(tick),
+		prev:     make(map[*Frame]SQT),
+	}
+	g.prevCamera = *g.Camera.Transform
+	return g
+}
+
+// Update advances the simulation by a single tick of dt seconds: it reads
+// the player's actions, moves the camera, steps the physics and shares out
+// power.
+func (g *Game) Update(dt float64) {
+	g.prevCamera = *g.Camera.Transform
+	g.prev = make(map[*Frame]SQT, len(g.Frames))
+	for _, f := range g.Frames {
+		g.prev[f] = *f.world()
+	}
+
+	if g.Actions != nil {
+		g.Actions.Poll()
+		if g.Actions.Pressed(Quit) {
+			g.Done = true
+		}
+		g.Camera.Update(g.Actions.Controls(), dt)
+	}
+	g.Physics.stepBy(dt)
+	for _, p := range g.Power {
+		p.Tick(dt)
+	}
+	g.Ticks++
+}
+
+// Advance runs as many ticks as fit into elapsed seconds plus the time
+// left over from previous calls, and returns how far the simulation is
+// through the next tick, from 0 to 1, for Render. At most MaxSteps ticks
+// are run; any more whole ticks are dropped so that a slow machine falls
+// behind real time rather than spiralling as it tries to catch up.
+func (g *Game) Advance(elapsed float64) (alpha float64) {
+	g.accumulator += elapsed
+	for steps := 0; g.accumulator >= g.Tick; steps++ {
+		if steps == g.MaxSteps {
+			g.accumulator = math.Mod(g.accumulator, g.Tick)
+			break
+		}
+		g.Update(g.Tick)
+		g.accumulator -= g.Tick
+	}
+	return g.accumulator / g.Tick
+}
+
+// RunHeadless runs n ticks without a window or real time, for tests and
+// servers.
+func (g *Game) RunHeadless(n int) {
+	for i := 0; i < n && !g.Done; i++ {
+		g.Update(g.Tick)
+	}
+}
+
+// Render calls draw for each frame with its modelview transformation,
+// from its local coordinates to those of the camera, as it was alpha of
+// the way from the previous tick to the current one. Frames added since
+// the last tick are drawn where they are.
+func (g *Game) Render(alpha float64, draw func(f *Frame, modelview *SQT)) {
+	view := g.prevCamera.Lerp(g.Camera.Transform, alpha).Inverse()
+	for _, f := range g.Frames {
+		world := f.world()
+		if prev, ok := g.prev[f]; ok {
+			world = prev.Lerp(world, alpha)
+		}
+		draw(f, view.Compose(world))
+	}
+}
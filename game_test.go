@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGameAdvance(t *testing.T) {
+	g := NewGame(0.25, nil)
+	if alpha := g.Advance(0.625); g.Ticks != 2 || alpha != 0.5 {
+		t.Errorf("Advance ran %d ticks with alpha %v, expected 2 and 0.5", g.Ticks, alpha)
+	}
+	if alpha := g.Advance(0.125); g.Ticks != 3 || alpha != 0 {
+		t.Errorf("Advance ran %d ticks with alpha %v, expected 3 and 0", g.Ticks, alpha)
+	}
+
+	// Catching up is limited, dropping whole ticks but keeping the fraction
+	g.MaxSteps = 4
+	if alpha := g.Advance(100.125); g.Ticks != 7 || alpha != 0.5 {
+		t.Errorf("Advance ran %d ticks with alpha %v, expected 7 and 0.5", g.Ticks, alpha)
+	}
+	if alpha := g.Advance(0.125); g.Ticks != 8 || alpha != 0 {
+		t.Errorf("Advance after catch-up ran %d ticks with alpha %v, expected 8 and 0", g.Ticks, alpha)
+	}
+}
+
+func TestGameHeadless(t *testing.T) {
+	script := make([]InputFrame, 10)
+	for i := range script {
+		script[i].Keys = []Key{"W"}
+	}
+	script = append(script, InputFrame{Keys: []Key{"Esc"}}, InputFrame{Keys: []Key{"W"}})
+
+	g := NewGame(0.125, NewActions(NewScriptedInput(script...), DefaultBindings()))
+	g.Camera.Speed = 2
+	g.RunHeadless(100)
+	if !g.Done || g.Ticks != 11 {
+		t.Errorf("RunHeadless ran %d ticks, expected to quit after 11", g.Ticks)
+	}
+	checkVector(t, "Camera after headless run", g.Camera.Transform.Translation(), Vec3{0, 0, -2.5})
+}
+
+func TestGameRender(t *testing.T) {
+	g := NewGame(0.25, nil)
+	f := NewFrame()
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	g.Frames = append(g.Frames, f)
+	g.Physics.AddBody(f).Velocity = Vec3{4, 0, 0}
+	g.Camera.Transform.SetTranslation(Vec3{0, 0, 10})
+	g.Camera.Look(math.Pi/2, 0)
+
+	alpha := g.Advance(0.375)
+	var drawn []*SQT
+	g.Render(alpha, func(f *Frame, modelview *SQT) {
+		drawn = append(drawn, modelview)
+	})
+	if len(drawn) != 1 {
+		t.Fatal("Render drew wrong number of frames", len(drawn))
+	}
+
+	// Half way between x = 0 and x = 1, seen by a camera looking along -x
+	expected := g.Camera.Transform.Inverse().Compose(&SQT{1, QuatIdentity, Vec3{0.5, 0, 0}})
+	checkPoints(t, "Render", drawn[0], expected)
+
+	// A frame added since the last tick is drawn where it is
+	h := NewFrame()
+	h.Transform.SetTranslation(Vec3{3, 0, 0})
+	g.Frames = append(g.Frames, h)
+	drawn = nil
+	g.Render(alpha, func(f *Frame, modelview *SQT) {
+		drawn = append(drawn, modelview)
+	})
+	checkPoints(t, "Render of new frame", drawn[1], g.Camera.Transform.Inverse().Compose(h.Transform))
+}
+
+func TestGamePower(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(0, 0, 0, panelBlock)
+	f.SetBlock(1, 0, 0, batteryBlock)
+	g := NewGame(0.25, nil)
+	grid := NewPowerGrid(f, powerParts)
+	g.Power = append(g.Power, grid)
+	g.RunHeadless(4)
+	if grid.Charge(1, 0, 0) != 4 {
+		t.Errorf("game ticks charged battery to %v, expected 4", grid.Charge(1, 0, 0))
+	}
+}
@@ -0,0 +1,106 @@
+// Package generate decides the blocks of seeded, procedurally generated
+// worlds. Generators only name block Ids, so they know nothing of frames
+// or chunks and the same seed always gives the same world.
+package generate
+
+import (
+	"math"
+)
+
+// Ids of the blocks placed by the generators, as defined in blocks.json.
+const (
+	Rock uint = 1
+	Ice  uint = 2
+)
+
+// Generator decides the block Id at each voxel of a generated world, zero
+// for empty space. Block must depend only on the coordinates and the
+// generator's settings, so that chunks can be generated in any order.
+type Generator interface {
+	Block(x, y, z int) uint
+}
+
+// Terrain generates rolling ground: rock below a surface whose height
+// varies with fractal noise, and nothing above.
+type Terrain struct {
+	Noise  *Noise
+	Height float64 // largest distance of the surface above or below y = 0
+	Scale  float64 // horizontal size of hills
+	Rock   uint
+}
+
+// NewTerrain creates rock terrain from seed.
+func NewTerrain(seed int64) *Terrain {
+	return &Terrain{NewNoise(seed), 24, 64, Rock}
+}
+
+// Block returns rock below the surface and empty space above.
+func (t *Terrain) Block(x, y, z int) uint {
+	h := t.Height * t.Noise.Fractal(float64(x)/t.Scale, 0.5, float64(z)/t.Scale, 4, 0.5)
+	if float64(y) < h {
+		return t.Rock
+	}
+	return 0
+}
+
+// Asteroid generates a lumpy ball of rock around Centre, with pockets of
+// ice inside it.
+type Asteroid struct {
+	Noise     *Noise
+	Centre    [3]float64
+	Radius    float64
+	Roughness float64 // how far the surface strays from a sphere, as a fraction of Radius
+	IceChance float64 // fraction of the interior which is ice, from 0 to 1
+	Rock, Ice uint
+}
+
+// NewAsteroid creates an asteroid of the given radius centred on the
+// origin from seed.
+func NewAsteroid(seed int64, radius float64) *Asteroid {
+	return &Asteroid{NewNoise(seed), [3]float64{}, radius, 0.3, 0.2, Rock, Ice}
+}
+
+// Block returns rock or ice inside the asteroid and empty space outside.
+func (a *Asteroid) Block(x, y, z int) uint {
+	dx := float64(x) + 0.5 - a.Centre[0]
+	dy := float64(y) + 0.5 - a.Centre[1]
+	dz := float64(z) + 0.5 - a.Centre[2]
+	s := 2 / a.Radius
+	r := a.Radius * (1 + a.Roughness*a.Noise.Fractal(dx*s, dy*s, dz*s, 3, 0.5))
+	if math.Sqrt(dx*dx+dy*dy+dz*dz) >= r {
+		return 0
+	}
+	s = 8 / a.Radius
+	if a.Noise.At(dx*s+100, dy*s+100, dz*s+100) > 0.5-a.IceChance {
+		return a.Ice
+	}
+	return a.Rock
+}
+
+// Caves carves tunnels through the blocks of another generator. Tunnels
+// follow the lines where two independent noise fields are both near zero.
+type Caves struct {
+	Base  Generator
+	Noise *Noise
+	Width float64 // how close to zero both fields must be, larger for wider tunnels
+	Scale float64 // size of the bends in the tunnels
+}
+
+// NewCaves creates caves through base from seed.
+func NewCaves(seed int64, base Generator) *Caves {
+	return &Caves{base, NewNoise(seed), 0.08, 32}
+}
+
+// Block returns the block of the base generator, or empty space inside a
+// tunnel.
+func (c *Caves) Block(x, y, z int) uint {
+	b := c.Base.Block(x, y, z)
+	if b == 0 {
+		return b
+	}
+	px, py, pz := float64(x)/c.Scale, float64(y)/c.Scale, float64(z)/c.Scale
+	if math.Abs(c.Noise.At(px, py, pz)) < c.Width && math.Abs(c.Noise.At(px+57.3, py+31.1, pz+17.9)) < c.Width {
+		return 0
+	}
+	return b
+}
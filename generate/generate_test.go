@@ -0,0 +1,51 @@
+package generate
+
+import (
+	"testing"
+)
+
+func TestAsteroid(t *testing.T) {
+	a := NewAsteroid(3, 20)
+	if a.Block(0, 0, 0) == 0 {
+		t.Error("Asteroid is hollow")
+	}
+	if a.Block(30, 0, 0) != 0 || a.Block(0, -30, 0) != 0 {
+		t.Error("Asteroid extends too far")
+	}
+	ice, rock := 0, 0
+	for x := -20; x < 20; x++ {
+		for y := -20; y < 20; y++ {
+			switch a.Block(x, y, 0) {
+			case a.Ice:
+				ice++
+			case a.Rock:
+				rock++
+			}
+		}
+	}
+	if ice == 0 || rock < ice {
+		t.Errorf("Asteroid has %d ice and %d rock, expected mostly rock with some ice", ice, rock)
+	}
+}
+
+func TestCaves(t *testing.T) {
+	base := NewTerrain(5)
+	c := NewCaves(6, base)
+	carved := 0
+	for x := 0; x < 64; x++ {
+		for y := -24; y < 24; y++ {
+			for z := 0; z < 64; z++ {
+				b, cb := base.Block(x, y, z), c.Block(x, y, z)
+				if b == 0 && cb != 0 {
+					t.Fatal("Caves added a block")
+				}
+				if b != 0 && cb == 0 {
+					carved++
+				}
+			}
+		}
+	}
+	if carved == 0 {
+		t.Error("Caves carved no tunnels")
+	}
+}
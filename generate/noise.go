@@ -0,0 +1,92 @@
+package generate
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Noise is seeded three-dimensional Perlin gradient noise. The same seed
+// always gives the same noise.
+type Noise struct {
+	perm [512]uint8
+}
+
+// NewNoise creates noise from seed.
+func NewNoise(seed int64) *Noise {
+	n := &Noise{}
+	for i, v := range rand.New(rand.NewSource(seed)).Perm(256) {
+		n.perm[i] = uint8(v)
+		n.perm[i+256] = uint8(v)
+	}
+	return n
+}
+
+// At returns the noise at (x, y, z), roughly in the range [-1, 1]. It is
+// zero at integer coordinates and varies smoothly over distances of about
+// one.
+func (n *Noise) At(x, y, z float64) float64 {
+	fx, fy, fz := math.Floor(x), math.Floor(y), math.Floor(z)
+	X, Y, Z := int(fx)&255, int(fy)&255, int(fz)&255
+	x, y, z = x-fx, y-fy, z-fz
+	u, v, w := fade(x), fade(y), fade(z)
+
+	p := &n.perm
+	a := int(p[X]) + Y
+	aa, ab := int(p[a])+Z, int(p[a+1])+Z
+	b := int(p[X+1]) + Y
+	ba, bb := int(p[b])+Z, int(p[b+1])+Z
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad(p[aa], x, y, z), grad(p[ba], x-1, y, z)),
+			lerp(u, grad(p[ab], x, y-1, z), grad(p[bb], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, grad(p[aa+1], x, y, z-1), grad(p[ba+1], x-1, y, z-1)),
+			lerp(u, grad(p[ab+1], x, y-1, z-1), grad(p[bb+1], x-1, y-1, z-1))))
+}
+
+// Fractal sums octaves of noise at (x, y, z), each at double the frequency
+// and persistence times the amplitude of the last, scaled back into the
+// range [-1, 1].
+func (n *Noise) Fractal(x, y, z float64, octaves int, persistence float64) float64 {
+	sum, amp, total := 0.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		sum += amp * n.At(x, y, z)
+		total += amp
+		amp *= persistence
+		x, y, z = x*2, y*2, z*2
+	}
+	return sum / total
+}
+
+// fade is the quintic curve 6t^5 - 15t^4 + 10t^3, which blends lattice
+// cells with continuous second derivatives.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// grad returns the dot product of (x, y, z) with one of twelve gradient
+// directions chosen by hash.
+func grad(hash uint8, x, y, z float64) float64 {
+	h := hash & 15
+	u, v := y, z
+	if h < 8 {
+		u = x
+	}
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
@@ -0,0 +1,40 @@
+package generate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNoise(t *testing.T) {
+	a, b := NewNoise(1), NewNoise(1)
+	c := NewNoise(2)
+	same, lo, hi := true, 0.0, 0.0
+	for i := 0; i < 1000; i++ {
+		x, y, z := float64(i)*0.37, float64(i)*0.11-20, float64(i%37)*0.53
+		v := a.At(x, y, z)
+		if v != b.At(x, y, z) {
+			t.Fatal("Noise with the same seed returned different values")
+		}
+		if v != c.At(x, y, z) {
+			same = false
+		}
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+
+		// Continuity
+		if d := math.Abs(v - a.At(x+1e-6, y, z)); d > 1e-5 {
+			t.Fatalf("Noise jumped by %v at (%v, %v, %v)", d, x, y, z)
+		}
+	}
+	if same {
+		t.Error("Noise with different seeds returned the same values")
+	}
+	if lo < -1.1 || hi > 1.1 || lo > -0.3 || hi < 0.3 {
+		t.Errorf("Noise returned values in [%v, %v], expected about [-1, 1]", lo, hi)
+	}
+	if v := a.At(3, -7, 12); v != 0 {
+		t.Error("Noise was not zero at integer coordinates", v)
+	}
+	if v := a.Fractal(0.3, 0.4, 0.5, 4, 0.5); v < -1 || v > 1 {
+		t.Error("Fractal returned value out of range", v)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+)
+
+// glErrorNames names the error codes returned by glGetError.
+var glErrorNames = map[uint32]string{
+	0x0500: "INVALID_ENUM",
+	0x0501: "INVALID_VALUE",
+	0x0502: "INVALID_OPERATION",
+	0x0503: "STACK_OVERFLOW",
+	0x0504: "STACK_UNDERFLOW",
+	0x0505: "OUT_OF_MEMORY",
+	0x0506: "INVALID_FRAMEBUFFER_OPERATION",
+	0x8031: "TABLE_TOO_LARGE",
+}
+
+// GLError is an error reported by OpenGL after an operation.
+type GLError struct {
+	Code uint32 // value returned by glGetError
+	Op   string // what was being done
+	Site string // file and line of the check
+}
+
+// Name returns the name of the error code.
+func (e *GLError) Name() string {
+	if name, ok := glErrorNames[e.Code]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", e.Code)
+}
+
+func (e *GLError) Error() string {
+	return fmt.Sprintf("%s: %s: GL error %s", e.Site, e.Op, e.Name())
+}
+
+// GLCheckMode selects what happens when OpenGL calls are checked for
+// errors.
+type GLCheckMode int
+
+const (
+	GLCheckReturn GLCheckMode = iota // errors are returned to the caller
+	GLCheckPanic                     // the first error panics, for debugging
+	GLCheckSkip                      // errors are not checked, for speed
+)
+
+// GLCheck is the mode used by every check. It implements flag.Value, so
+// it can be set from the command line.
+var GLCheck = GLCheckReturn
+
+var glCheckNames = []string{"return", "panic", "skip"}
+
+func (m *GLCheckMode) String() string {
+	if int(*m) < len(glCheckNames) {
+		return glCheckNames[*m]
+	}
+	return fmt.Sprintf("GLCheckMode(%d)", int(*m))
+}
+
+// Set sets the mode from its name.
+func (m *GLCheckMode) Set(s string) error {
+	for i, name := range glCheckNames {
+		if s == name {
+			*m = GLCheckMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown GL check mode %q", s)
+}
+
+// maxGLErrors limits how many pending errors are cleared by a check, in
+// case glGetError keeps failing without a context.
+const maxGLErrors = 16
+
+// checkErrors reads the pending errors with getError and returns the
+// first as a *GLError, clearing the others. The error records the call
+// site skip frames above the caller of checkErrors. What happens depends
+// on GLCheck.
+func checkErrors(op string, skip int, getError func() uint32) error {
+	if GLCheck == GLCheckSkip {
+		return nil
+	}
+	code := getError()
+	if code == 0 {
+		return nil
+	}
+	for i := 0; i < maxGLErrors && getError() != 0; i++ {
+	}
+
+	site := "unknown"
+	if _, file, line, ok := runtime.Caller(skip + 1); ok {
+		site = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+	e := &GLError{code, op, site}
+	if GLCheck == GLCheckPanic {
+		panic(e)
+	}
+	return e
+}
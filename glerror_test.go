@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// fakeErrors returns a getError function which reports codes in turn and
+// then no error, and counts the calls made to it.
+func fakeErrors(calls *int, codes ...uint32) func() uint32 {
+	return func() uint32 {
+		*calls++
+		if len(codes) == 0 {
+			return 0
+		}
+		c := codes[0]
+		codes = codes[1:]
+		return c
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	defer func(m GLCheckMode) { GLCheck = m }(GLCheck)
+	GLCheck = GLCheckReturn
+
+	calls := 0
+	if e := checkErrors("nothing", 0, fakeErrors(&calls)); e != nil || calls != 1 {
+		t.Error("checkErrors returned error without GL error", e)
+	}
+
+	calls = 0
+	e := checkErrors("drawing", 0, fakeErrors(&calls, 0x0502, 0x0501))
+	ge, ok := e.(*GLError)
+	if !ok {
+		t.Fatal("checkErrors did not return *GLError", e)
+	}
+	if ge.Code != 0x0502 || ge.Name() != "INVALID_OPERATION" || ge.Op != "drawing" {
+		t.Error("checkErrors returned wrong error", ge)
+	}
+	if calls != 3 {
+		t.Errorf("checkErrors called glGetError %d times, expected 3 to clear the errors", calls)
+	}
+	if !strings.HasPrefix(ge.Site, "glerror_test.go:") {
+		t.Error("checkErrors recorded wrong call site " + ge.Site)
+	}
+	if !strings.HasSuffix(e.Error(), ": drawing: GL error INVALID_OPERATION") {
+		t.Error("GLError returned wrong message: " + e.Error())
+	}
+	if (&GLError{Code: 0x1234}).Name() != "0x1234" {
+		t.Error("GLError did not name unknown code by number")
+	}
+
+	// An error which never clears does not hang the check
+	calls = 0
+	checkErrors("stuck", 0, func() uint32 { calls++; return 0x0505 })
+	if calls != maxGLErrors+1 {
+		t.Errorf("checkErrors called glGetError %d times for stuck error", calls)
+	}
+}
+
+func TestCheckErrorsModes(t *testing.T) {
+	defer func(m GLCheckMode) { GLCheck = m }(GLCheck)
+
+	GLCheck = GLCheckSkip
+	calls := 0
+	if e := checkErrors("skipped", 0, fakeErrors(&calls, 0x0500)); e != nil || calls != 0 {
+		t.Error("checkErrors checked errors in skip mode")
+	}
+
+	GLCheck = GLCheckPanic
+	func() {
+		defer func() {
+			if _, ok := recover().(*GLError); !ok {
+				t.Error("checkErrors did not panic with *GLError in panic mode")
+			}
+		}()
+		checkErrors("panicking", 0, fakeErrors(&calls, 0x0500))
+	}()
+	if e := checkErrors("no error", 0, fakeErrors(&calls)); e != nil {
+		t.Error("checkErrors returned error without GL error in panic mode", e)
+	}
+}
+
+func TestGLCheckModeFlag(t *testing.T) {
+	var m GLCheckMode
+	for i, name := range []string{"return", "panic", "skip"} {
+		if err := m.Set(name); err != nil || m != GLCheckMode(i) {
+			t.Error("Set did not set mode " + name)
+		}
+		if m.String() != name {
+			t.Error("String returned " + m.String() + ", expected " + name)
+		}
+	}
+	if m.Set("debug") == nil {
+		t.Error("Set accepted unknown mode")
+	}
+}
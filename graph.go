@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+)
+
+// ErrFrameCycle is returned by SetParent when the new parent is the frame
+// itself or one of its descendants.
+var ErrFrameCycle = errors.New("frame cannot be its own ancestor")
+
+// Parent returns the frame that f is attached to, or nil if f is a root
+// frame whose Transform is relative to the world.
+func (f *Frame) Parent() *Frame {
+	return f.parent
+}
+
+// Children returns the frames attached to f.
+func (f *Frame) Children() []*Frame {
+	return append([]*Frame(nil), f.children...)
+}
+
+// SetParent attaches f to parent, or detaches it if parent is nil, keeping
+// its world pose by adjusting f.Transform.
+func (f *Frame) SetParent(parent *Frame) error {
+	for a := parent; a != nil; a = a.parent {
+		if a == f {
+			return ErrFrameCycle
+		}
+	}
+
+	world := f.world()
+	if parent == nil {
+		*f.Transform = *world
+	} else {
+		*f.Transform = *parent.world().Inverse().Compose(world)
+	}
+
+	if f.parent != nil {
+		siblings := f.parent.children
+		for i, c := range siblings {
+			if c == f {
+				f.parent.children = append(siblings[:i], siblings[i+1:]...)
+				break
+			}
+		}
+	}
+	f.parent = parent
+	if parent != nil {
+		parent.children = append(parent.children, f)
+	}
+	f.cached = false
+	return nil
+}
+
+// World returns the transformation from the local coordinates of f to
+// world coordinates, composing the transforms of f and all its ancestors.
+func (f *Frame) World() *SQT {
+	w := *f.world()
+	return &w
+}
+
+// world returns the world transformation of f without copying it. The
+// result is cached until the transform of f or one of its ancestors
+// changes, so it must not be modified.
+func (f *Frame) world() *SQT {
+	if f.parent == nil {
+		return f.Transform
+	}
+	pw := f.parent.world()
+	if !f.cached || f.cachedLocal != *f.Transform || f.cachedParent != *pw {
+		f.cachedWorld = *pw.Compose(f.Transform)
+		f.cachedLocal = *f.Transform
+		f.cachedParent = *pw
+		f.cached = true
+	}
+	return &f.cachedWorld
+}
+
+// TransformTo returns the transformation from the local coordinates of f
+// to the local coordinates of g, or to world coordinates if g is nil.
+func (f *Frame) TransformTo(g *Frame) *SQT {
+	if g == nil {
+		return f.World()
+	}
+	return g.world().Inverse().Compose(f.world())
+}
+
+// PointTo converts the point p from the local coordinates of f to the
+// local coordinates of g, or to world coordinates if g is nil.
+func (f *Frame) PointTo(g *Frame, p Vec3) Vec3 {
+	return f.TransformTo(g).TransformAbs(p)
+}
+
+// VectorTo converts the direction v from the local coordinates of f to the
+// local coordinates of g, or to world coordinates if g is nil.
+func (f *Frame) VectorTo(g *Frame, v Vec3) Vec3 {
+	return f.TransformTo(g).TransformRel(v)
+}
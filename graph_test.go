@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWorld(t *testing.T) {
+	a := NewFrame()
+	a.Transform.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{0, 0, 1}))
+	a.Transform.SetTranslation(Vec3{10, 0, 0})
+
+	b := NewFrame()
+	b.Transform.SetScale(2)
+	b.Transform.SetTranslation(Vec3{1, 0, 0})
+	b.SetParent(a)
+
+	c := NewFrame()
+	c.Transform.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{1, 0, 0}))
+	c.SetParent(b)
+
+	if b.Parent() != a || c.Parent() != b || a.Parent() != nil {
+		t.Error("SetParent did not set parents")
+	}
+	if len(a.Children()) != 1 || a.Children()[0] != b {
+		t.Error("SetParent did not add child")
+	}
+
+	// SetParent keeps the world pose, so the local transforms are now
+	// relative to the parents; reset them to test composition.
+	b.Transform = NewSQT()
+	b.Transform.SetScale(2)
+	b.Transform.SetTranslation(Vec3{1, 0, 0})
+	c.Transform = NewSQT()
+	c.Transform.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{1, 0, 0}))
+
+	expected := a.Transform.Compose(b.Transform).Compose(c.Transform)
+	checkPoints(t, "World", c.World(), expected)
+
+	// Moving an ancestor must invalidate the cached world transform
+	a.Transform.Translate(Vec3{0, 5, 0})
+	expected = a.Transform.Compose(b.Transform).Compose(c.Transform)
+	checkPoints(t, "World after moving grandparent", c.World(), expected)
+
+	b.Transform.Scale(3)
+	expected = a.Transform.Compose(b.Transform).Compose(c.Transform)
+	checkPoints(t, "World after scaling parent", c.World(), expected)
+
+	a.Transform = NewSQT()
+	expected = b.Transform.Compose(c.Transform)
+	checkPoints(t, "World after replacing grandparent transform", c.World(), expected)
+
+	// The result must not alias the cache
+	w := c.World()
+	w.Translate(Vec3{100, 0, 0})
+	checkPoints(t, "World after modifying result", c.World(), expected)
+}
+
+func TestSetParent(t *testing.T) {
+	a := NewFrame()
+	a.Transform.SetRotation(QuatAxisAngle(math.Pi/3, Vec3{0, 1, 0}))
+	a.Transform.SetTranslation(Vec3{-4, 2, 7})
+	a.Transform.SetScale(0.5)
+
+	b := NewFrame()
+	b.Transform.SetRotation(QuatAxisAngle(math.Pi/4, Vec3{0, 0, 1}))
+	b.Transform.SetTranslation(Vec3{1, 2, 3})
+
+	c := NewFrame()
+	c.Transform.SetTranslation(Vec3{3, 0, -1})
+	c.Transform.SetScale(3)
+
+	before := c.World()
+	c.SetParent(a)
+	checkPoints(t, "SetParent", c.World(), before)
+
+	c.SetParent(b)
+	checkPoints(t, "SetParent to sibling", c.World(), before)
+	if len(a.Children()) != 0 {
+		t.Error("SetParent did not remove child from old parent")
+	}
+
+	b.SetParent(a)
+	before = c.World()
+	c.SetParent(nil)
+	checkPoints(t, "SetParent to nil", c.World(), before)
+	checkPoints(t, "SetParent to nil", c.Transform, before)
+	if len(b.Children()) != 0 || c.Parent() != nil {
+		t.Error("SetParent did not detach frame")
+	}
+
+	if err := a.SetParent(a); err != ErrFrameCycle {
+		t.Error("SetParent did not refuse to attach frame to itself")
+	}
+	if err := a.SetParent(b); err != ErrFrameCycle {
+		t.Error("SetParent did not refuse to attach frame to its child")
+	}
+}
+
+func TestPointTo(t *testing.T) {
+	root := NewFrame()
+	root.Transform.SetTranslation(Vec3{0, 0, 5})
+
+	a := NewFrame()
+	a.SetParent(root)
+	a.Transform.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{0, 0, 1}))
+	a.Transform.SetTranslation(Vec3{1, 0, 0})
+
+	b := NewFrame()
+	b.SetParent(root)
+	b.Transform.SetScale(2)
+	b.Transform.SetTranslation(Vec3{0, 1, 0})
+
+	// a's local x axis is root's y axis: (1, 0, 0) in a is (1, 1, 0) in
+	// root, which is (0.5, 0, 0) in b and (1, 1, 5) in the world.
+	checkPoint(t, "PointTo", a.PointTo(b, Vec3{1, 0, 0}), Vec3{0.5, 0, 0})
+	checkPoint(t, "PointTo world", a.PointTo(nil, Vec3{1, 0, 0}), Vec3{1, 1, 5})
+	checkPoint(t, "PointTo inverse", b.PointTo(a, Vec3{0.5, 0, 0}), Vec3{1, 0, 0})
+	checkPoint(t, "VectorTo", a.VectorTo(b, Vec3{1, 0, 0}), Vec3{0, 0.5, 0})
+}
+
+func checkPoint(t *testing.T, desc string, p, expected Vec3) {
+	if p.Sub(expected).Length() > 1e-14 {
+		t.Errorf("%s returned %v, expected %v", desc, p, expected)
+	}
+}
+
+// checkPoints checks that s and expected transform a set of points the same.
+func checkPoints(t *testing.T, desc string, s, expected *SQT) {
+	points := []Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {-3, 7, 2}}
+	for _, p := range points {
+		v := s.TransformAbs(p)
+		e := expected.TransformAbs(p)
+		if v.Sub(e).Length() > 1e-12 {
+			t.Errorf("%s transformed %v to %v, expected %v", desc, p, v, e)
+			return
+		}
+	}
+}
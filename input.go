@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Key names a key or mouse button independently of the windowing library.
+// Letters and digits are named by themselves ("W", "1"); other keys and the
+// mouse buttons are listed in specialKeys.
+type Key string
+
+// specialKeys lists the valid names of keys and buttons other than letters
+// and digits.
+var specialKeys = map[Key]bool{
+	"Esc": true, "Space": true, "Enter": true, "Tab": true,
+	"LeftShift": true, "LeftCtrl": true,
+	"Up": true, "Down": true, "Left": true, "Right": true,
+	"MouseLeft": true, "MouseRight": true, "MouseMiddle": true,
+}
+
+// Valid reports whether k names a known key or mouse button.
+func (k Key) Valid() bool {
+	if len(k) == 1 {
+		c := k[0]
+		return 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+	}
+	return specialKeys[k]
+}
+
+// Input is a source of keyboard and mouse state. Poll samples the devices,
+// and the other methods report the state at the last Poll; Pressed and
+// Released report changes since the Poll before.
+type Input interface {
+	Poll()
+	Held(k Key) bool
+	Pressed(k Key) bool
+	Released(k Key) bool
+	MousePos() (x, y float64)
+}
+
+// keyState implements the queries of Input for implementations which
+// record the keys held at each Poll.
+type keyState struct {
+	held, prev map[Key]bool
+	x, y       float64
+}
+
+// set replaces the held keys and mouse position with those of a new Poll.
+func (s *keyState) set(held map[Key]bool, x, y float64) {
+	s.prev, s.held = s.held, held
+	s.x, s.y = x, y
+}
+
+// Held reports whether k was held at the last Poll.
+func (s *keyState) Held(k Key) bool {
+	return s.held[k]
+}
+
+// Pressed reports whether k was held at the last Poll but not the one
+// before.
+func (s *keyState) Pressed(k Key) bool {
+	return s.held[k] && !s.prev[k]
+}
+
+// Released reports whether k was held at the Poll before last but not the
+// last.
+func (s *keyState) Released(k Key) bool {
+	return !s.held[k] && s.prev[k]
+}
+
+// MousePos returns the position of the mouse at the last Poll.
+func (s *keyState) MousePos() (x, y float64) {
+	return s.x, s.y
+}
+
+// InputFrame is the state of the devices at one Poll of a ScriptedInput.
+type InputFrame struct {
+	Keys   []Key   `json:"keys"`
+	MouseX float64 `json:"mouse_x"`
+	MouseY float64 `json:"mouse_y"`
+}
+
+// ScriptedInput plays back a fixed sequence of device states, one per
+// Poll, for tests and replays. After the script ends no keys are held and
+// the mouse stays where it was.
+type ScriptedInput struct {
+	keyState
+	Script []InputFrame
+	next   int
+}
+
+// NewScriptedInput creates an input which plays back script.
+func NewScriptedInput(script ...InputFrame) *ScriptedInput {
+	return &ScriptedInput{Script: script}
+}
+
+// Poll advances to the next frame of the script.
+func (s *ScriptedInput) Poll() {
+	if s.next >= len(s.Script) {
+		s.set(nil, s.x, s.y)
+		return
+	}
+	f := s.Script[s.next]
+	s.next++
+	held := make(map[Key]bool, len(f.Keys))
+	for _, k := range f.Keys {
+		held[k] = true
+	}
+	s.set(held, f.MouseX, f.MouseY)
+}
+
+// Action names something the player can do, which is bound to keys.
+type Action string
+
+const (
+	MoveForward Action = "move_forward"
+	MoveBack    Action = "move_back"
+	MoveLeft    Action = "move_left"
+	MoveRight   Action = "move_right"
+	MoveUp      Action = "move_up"
+	MoveDown    Action = "move_down"
+	Mine        Action = "mine"
+	Place       Action = "place"
+	Quit        Action = "quit"
+)
+
+// Bindings maps each action to the keys which trigger it.
+type Bindings map[Action][]Key
+
+// DefaultBindings returns the bindings used when no configuration is
+// loaded.
+func DefaultBindings() Bindings {
+	return Bindings{
+		MoveForward: {"W", "Up"},
+		MoveBack:    {"S", "Down"},
+		MoveLeft:    {"A", "Left"},
+		MoveRight:   {"D", "Right"},
+		MoveUp:      {"Space"},
+		MoveDown:    {"LeftShift"},
+		Mine:        {"MouseLeft"},
+		Place:       {"MouseRight"},
+		Quit:        {"Esc"},
+	}
+}
+
+// LoadBindings reads bindings from a JSON object mapping action names to
+// arrays of key names. Actions missing from the object keep their default
+// bindings, and an empty array unbinds an action.
+func LoadBindings(rd io.Reader) (Bindings, error) {
+	var file map[Action][]Key
+	if err := json.NewDecoder(rd).Decode(&file); err != nil {
+		return nil, fmt.Errorf("reading bindings: %w", err)
+	}
+
+	b := DefaultBindings()
+	for a, keys := range file {
+		if _, ok := b[a]; !ok {
+			return nil, fmt.Errorf("unknown action %q", a)
+		}
+		for _, k := range keys {
+			if !k.Valid() {
+				return nil, fmt.Errorf("action %q bound to unknown key %q", a, k)
+			}
+		}
+		b[a] = keys
+	}
+	return b, nil
+}
+
+// LoadBindingsFile reads bindings from the named JSON file.
+func LoadBindingsFile(name string) (b Bindings, err error) {
+	err = loadFile(name, func(rd io.Reader) (err error) {
+		b, err = LoadBindings(rd)
+		return
+	})
+	return
+}
+
+// Actions translates the keys of an Input into actions through Bindings,
+// so that game logic never sees individual keys.
+type Actions struct {
+	Input    Input
+	Bindings Bindings
+	mouseX   float64
+	mouseY   float64
+	dx, dy   float64
+	polled   bool
+}
+
+// NewActions creates actions read from in with the bindings b.
+func NewActions(in Input, b Bindings) *Actions {
+	return &Actions{Input: in, Bindings: b}
+}
+
+// Poll samples the input. The mouse movement of the first Poll is zero.
+func (a *Actions) Poll() {
+	a.Input.Poll()
+	x, y := a.Input.MousePos()
+	if a.polled {
+		a.dx, a.dy = x-a.mouseX, y-a.mouseY
+	}
+	a.mouseX, a.mouseY, a.polled = x, y, true
+}
+
+// Held reports whether any key bound to the action is held.
+func (a *Actions) Held(act Action) bool {
+	for _, k := range a.Bindings[act] {
+		if a.Input.Held(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pressed reports whether the action started at the last Poll: one of its
+// keys was pressed and none was held before.
+func (a *Actions) Pressed(act Action) bool {
+	return a.Held(act) && !a.wasHeld(act)
+}
+
+// Released reports whether the action ended at the last Poll: none of its
+// keys is held but one was before.
+func (a *Actions) Released(act Action) bool {
+	return !a.Held(act) && a.wasHeld(act)
+}
+
+// wasHeld reports whether any key bound to the action was held at the
+// Poll before last.
+func (a *Actions) wasHeld(act Action) bool {
+	for _, k := range a.Bindings[act] {
+		if a.Input.Held(k) && !a.Input.Pressed(k) || a.Input.Released(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// MouseDelta returns the movement of the mouse since the Poll before last.
+func (a *Actions) MouseDelta() (dx, dy float64) {
+	return a.dx, a.dy
+}
+
+// Controls returns the camera controls requested by the held actions and
+// the mouse movement.
+func (a *Actions) Controls() Controls {
+	return Controls{
+		Forward: a.Held(MoveForward),
+		Back:    a.Held(MoveBack),
+		Left:    a.Held(MoveLeft),
+		Right:   a.Held(MoveRight),
+		Up:      a.Held(MoveUp),
+		Down:    a.Held(MoveDown),
+		LookX:   a.dx,
+		LookY:   a.dy,
+	}
+}
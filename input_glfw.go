@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/go-gl/glfw"
+)
+
+// glfwKeys maps the names of special keys to glfw key codes. Letters and
+// digits use their character as the code.
+var glfwKeys = map[Key]int{
+	"Esc":       glfw.KeyEsc,
+	"Space":     glfw.KeySpace,
+	"Enter":     glfw.KeyEnter,
+	"Tab":       glfw.KeyTab,
+	"LeftShift": glfw.KeyLshift,
+	"LeftCtrl":  glfw.KeyLctrl,
+	"Up":        glfw.KeyUp,
+	"Down":      glfw.KeyDown,
+	"Left":      glfw.KeyLeft,
+	"Right":     glfw.KeyRight,
+}
+
+// glfwButtons maps the names of mouse buttons to glfw button codes.
+var glfwButtons = map[Key]int{
+	"MouseLeft":   glfw.MouseLeft,
+	"MouseRight":  glfw.MouseRight,
+	"MouseMiddle": glfw.MouseMiddle,
+}
+
+// GLFWInput reads the keyboard and mouse of the glfw window.
+type GLFWInput struct {
+	keyState
+}
+
+// Poll samples every named key and button, and the mouse position.
+func (g *GLFWInput) Poll() {
+	held := make(map[Key]bool)
+	for c := byte('A'); c <= 'Z'; c++ {
+		if glfw.Key(int(c)) == glfw.KeyPress {
+			held[Key(c)] = true
+		}
+	}
+	for c := byte('0'); c <= '9'; c++ {
+		if glfw.Key(int(c)) == glfw.KeyPress {
+			held[Key(c)] = true
+		}
+	}
+	for k, code := range glfwKeys {
+		if glfw.Key(code) == glfw.KeyPress {
+			held[k] = true
+		}
+	}
+	for k, code := range glfwButtons {
+		if glfw.MouseButton(code) == glfw.KeyPress {
+			held[k] = true
+		}
+	}
+	x, y := glfw.MousePos()
+	g.set(held, float64(x), float64(y))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScriptedInput(t *testing.T) {
+	in := NewScriptedInput(
+		InputFrame{Keys: []Key{"W"}},
+		InputFrame{Keys: []Key{"W", "MouseLeft"}, MouseX: 5},
+		InputFrame{MouseX: 7, MouseY: 1},
+	)
+
+	in.Poll()
+	if !in.Held("W") || !in.Pressed("W") || in.Released("W") {
+		t.Error("ScriptedInput did not press W")
+	}
+	in.Poll()
+	if !in.Held("W") || in.Pressed("W") || !in.Pressed("MouseLeft") {
+		t.Error("ScriptedInput did not hold W and press MouseLeft")
+	}
+	if x, y := in.MousePos(); x != 5 || y != 0 {
+		t.Error("ScriptedInput returned wrong mouse position", x, y)
+	}
+	in.Poll()
+	if in.Held("W") || !in.Released("W") || !in.Released("MouseLeft") {
+		t.Error("ScriptedInput did not release keys")
+	}
+	in.Poll()
+	if in.Released("W") {
+		t.Error("ScriptedInput released W twice")
+	}
+	if x, y := in.MousePos(); x != 7 || y != 1 {
+		t.Error("ScriptedInput moved mouse after end of script", x, y)
+	}
+}
+
+func TestActions(t *testing.T) {
+	a := NewActions(NewScriptedInput(
+		InputFrame{Keys: []Key{"W"}, MouseX: 10, MouseY: 10},
+		InputFrame{Keys: []Key{"W", "Up", "D"}, MouseX: 13, MouseY: 8},
+		InputFrame{Keys: []Key{"Up"}, MouseX: 13, MouseY: 8},
+		InputFrame{},
+	), DefaultBindings())
+
+	a.Poll()
+	if !a.Pressed(MoveForward) || !a.Held(MoveForward) {
+		t.Error("Actions did not start move_forward")
+	}
+	if c := a.Controls(); c != (Controls{Forward: true}) {
+		t.Error("Actions returned wrong controls for first poll", c)
+	}
+
+	a.Poll()
+	if a.Pressed(MoveForward) {
+		t.Error("Actions started move_forward again when a second key was pressed")
+	}
+	expected := Controls{Forward: true, Right: true, LookX: 3, LookY: -2}
+	if c := a.Controls(); c != expected {
+		t.Error("Actions returned wrong controls", c, expected)
+	}
+
+	a.Poll()
+	if !a.Held(MoveForward) || a.Released(MoveForward) || !a.Released(MoveRight) {
+		t.Error("Actions ended move_forward while a key was held")
+	}
+	if dx, dy := a.MouseDelta(); dx != 0 || dy != 0 {
+		t.Error("Actions returned mouse movement for still mouse", dx, dy)
+	}
+
+	a.Poll()
+	if !a.Released(MoveForward) || a.Held(MoveForward) {
+		t.Error("Actions did not end move_forward")
+	}
+}
+
+func TestLoadBindings(t *testing.T) {
+	b, err := LoadBindings(strings.NewReader(`{"mine": ["E", "MouseLeft"], "place": []}`))
+	if err != nil {
+		t.Fatal("LoadBindings returned error: " + err.Error())
+	}
+	if !reflect.DeepEqual(b[Mine], []Key{"E", "MouseLeft"}) || len(b[Place]) != 0 {
+		t.Error("LoadBindings did not rebind actions", b)
+	}
+	if !reflect.DeepEqual(b[MoveForward], DefaultBindings()[MoveForward]) {
+		t.Error("LoadBindings did not keep default binding", b)
+	}
+
+	a := NewActions(NewScriptedInput(InputFrame{Keys: []Key{"E", "MouseRight"}}), b)
+	a.Poll()
+	if !a.Pressed(Mine) || a.Held(Place) {
+		t.Error("Actions did not use loaded bindings")
+	}
+
+	for _, s := range []string{
+		`{"fly": ["F"]}`,
+		`{"mine": ["Mouse4"]}`,
+		`{"mine": ["e"]}`,
+		`["W"]`,
+	} {
+		if _, err := LoadBindings(strings.NewReader(s)); err == nil {
+			t.Error("LoadBindings accepted invalid bindings " + s)
+		}
+	}
+}
+
+func TestLoadBindingsFile(t *testing.T) {
+	b, err := LoadBindingsFile("bindings.json")
+	if err != nil {
+		t.Fatal("LoadBindingsFile returned error for bindings.json: " + err.Error())
+	}
+	if !reflect.DeepEqual(b, DefaultBindings()) {
+		t.Error("bindings.json does not match the default bindings")
+	}
+}
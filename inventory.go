@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Errors returned when an inventory change cannot be made. Changes are
+// all or nothing, so nothing is moved when one is returned.
+var (
+	ErrNoRoom   = errors.New("not enough room")
+	ErrShortage = errors.New("not enough held")
+)
+
+// capacityTolerance allows for rounding when adding up masses and volumes.
+const capacityTolerance = 1e-9
+
+// Stack is an amount of a single resource.
+type Stack struct {
+	Resource string
+	Count    uint
+}
+
+// Inventory holds stacks of resources, limited by their total mass and
+// volume.
+type Inventory struct {
+	Name      string            // identifies the inventory in ledger events
+	MaxMass   float64           // largest total mass held
+	MaxVolume float64           // largest total volume held
+	Resources *ResourceRegistry // resource definitions, DefaultResources if nil
+	Ledger    *Ledger           // records every change, if not nil
+	stacks    map[string]uint
+}
+
+// NewInventory creates an empty inventory with the given limits. Use
+// math.Inf(1) for no limit.
+func NewInventory(name string, maxMass, maxVolume float64) *Inventory {
+	return &Inventory{
+		Name:      name,
+		MaxMass:   maxMass,
+		MaxVolume: maxVolume,
+		stacks:    make(map[string]uint),
+	}
+}
+
+// registry returns the resource registry used by the inventory.
+func (inv *Inventory) registry() *ResourceRegistry {
+	if inv.Resources != nil {
+		return inv.Resources
+	}
+	return DefaultResources
+}
+
+// Count returns the amount of the named resource held.
+func (inv *Inventory) Count(resource string) uint {
+	return inv.stacks[resource]
+}
+
+// Stacks returns the resources held, sorted by name.
+func (inv *Inventory) Stacks() []Stack {
+	stacks := make([]Stack, 0, len(inv.stacks))
+	for r, n := range inv.stacks {
+		stacks = append(stacks, Stack{r, n})
+	}
+	sort.Slice(stacks, func(i, j int) bool {
+		return stacks[i].Resource < stacks[j].Resource
+	})
+	return stacks
+}
+
+// Mass returns the total mass of the resources held.
+func (inv *Inventory) Mass() (m float64) {
+	reg := inv.registry()
+	for r, n := range inv.stacks {
+		m += reg.Def(r).Mass * float64(n)
+	}
+	return
+}
+
+// Volume returns the total volume of the resources held.
+func (inv *Inventory) Volume() (v float64) {
+	reg := inv.registry()
+	for r, n := range inv.stacks {
+		v += reg.Def(r).Volume * float64(n)
+	}
+	return
+}
+
+// Room returns the largest amount of the named resource which could be
+// added without exceeding either limit.
+func (inv *Inventory) Room(resource string) uint {
+	def := inv.registry().Def(resource)
+	room := math.Inf(1)
+	if def.Mass > 0 {
+		room = math.Min(room, (inv.MaxMass-inv.Mass()+capacityTolerance)/def.Mass)
+	}
+	if def.Volume > 0 {
+		room = math.Min(room, (inv.MaxVolume-inv.Volume()+capacityTolerance)/def.Volume)
+	}
+	if room < 0 {
+		return 0
+	}
+	if room >= math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint(room)
+}
+
+// Add puts n of the named resource into the inventory from outside,
+// recording reason in the ledger.
+func (inv *Inventory) Add(resource string, n uint, reason string) error {
+	err := inv.canAdd(resource, n)
+	if err == nil {
+		inv.stacks[resource] += n
+	}
+	inv.Ledger.record("", inv.Name, resource, n, reason, err)
+	return err
+}
+
+// Remove takes n of the named resource out of the inventory, recording
+// reason in the ledger.
+func (inv *Inventory) Remove(resource string, n uint, reason string) error {
+	err := inv.canRemove(resource, n)
+	if err == nil {
+		inv.take(resource, n)
+	}
+	inv.Ledger.record(inv.Name, "", resource, n, reason, err)
+	return err
+}
+
+// Transfer moves n of the named resource from inv into to, recording
+// reason in the ledger. The event is recorded once, in the ledger of inv,
+// or of to if inv has none.
+func (inv *Inventory) Transfer(to *Inventory, resource string, n uint, reason string) error {
+	err := inv.canRemove(resource, n)
+	if err == nil && to != inv {
+		err = to.canAdd(resource, n)
+	}
+	if err == nil && to != inv {
+		inv.take(resource, n)
+		to.stacks[resource] += n
+	}
+	l := inv.Ledger
+	if l == nil {
+		l = to.Ledger
+	}
+	l.record(inv.Name, to.Name, resource, n, reason, err)
+	return err
+}
+
+func (inv *Inventory) canAdd(resource string, n uint) error {
+	if n > inv.Room(resource) {
+		return fmt.Errorf("adding %d %s to %s: %w", n, resource, inv.Name, ErrNoRoom)
+	}
+	return nil
+}
+
+func (inv *Inventory) canRemove(resource string, n uint) error {
+	if n > inv.stacks[resource] {
+		return fmt.Errorf("removing %d %s from %s: %w", n, resource, inv.Name, ErrShortage)
+	}
+	return nil
+}
+
+func (inv *Inventory) take(resource string, n uint) {
+	if inv.stacks[resource] -= n; inv.stacks[resource] == 0 {
+		delete(inv.stacks, resource)
+	}
+}
+
+// collect credits inv with the yield of Block old mined from (x, y, z) of
+// a frame using reg, returning ErrNoRoom if it does not fit.
+func (inv *Inventory) collect(reg *BlockRegistry, x, y, z int, old Block) error {
+	def := reg.Def(old.Id)
+	if def.Yield == "" || def.YieldCount == 0 {
+		return nil
+	}
+	return inv.Add(def.Yield, def.YieldCount, fmt.Sprintf("mined %s at (%d, %d, %d)", def.Name, x, y, z))
+}
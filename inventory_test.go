@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func testResources() *ResourceRegistry {
+	r := NewResourceRegistry()
+	r.Register(ResourceDef{"iron", 5, 1})
+	r.Register(ResourceDef{"water", 1, 1})
+	r.Register(ResourceDef{"foam", 0.1, 2})
+	return r
+}
+
+func newTestInventory(name string, maxMass, maxVolume float64, l *Ledger) *Inventory {
+	inv := NewInventory(name, maxMass, maxVolume)
+	inv.Resources = testResources()
+	inv.Ledger = l
+	return inv
+}
+
+func TestInventoryCapacity(t *testing.T) {
+	inv := newTestInventory("robot", 20, 10, nil)
+
+	if inv.Room("iron") != 4 || inv.Room("water") != 10 || inv.Room("foam") != 5 {
+		t.Error("Room returned wrong room in empty inventory")
+	}
+	if err := inv.Add("iron", 3, "test"); err != nil {
+		t.Fatal("Add returned error: " + err.Error())
+	}
+	if inv.Mass() != 15 || inv.Volume() != 3 {
+		t.Errorf("inventory has mass %g and volume %g, expected 15 and 3", inv.Mass(), inv.Volume())
+	}
+
+	// Mass limits iron, volume limits foam
+	if inv.Room("iron") != 1 || inv.Room("water") != 5 || inv.Room("foam") != 3 {
+		t.Error("Room returned wrong room in part full inventory")
+	}
+	if err := inv.Add("iron", 2, "test"); !errors.Is(err, ErrNoRoom) {
+		t.Error("Add did not return ErrNoRoom when over mass limit", err)
+	}
+	if err := inv.Add("foam", 4, "test"); !errors.Is(err, ErrNoRoom) {
+		t.Error("Add did not return ErrNoRoom when over volume limit", err)
+	}
+	if inv.Count("iron") != 3 || inv.Count("foam") != 0 {
+		t.Error("failed Add changed inventory")
+	}
+
+	if err := inv.Add("foam", 3, "test"); err != nil {
+		t.Error("Add refused foam which fits", err)
+	}
+	if err := inv.Add("water", 1, "test"); err != nil || inv.Room("water") != 0 {
+		t.Error("Add did not fill inventory", err)
+	}
+
+	// Filling exactly to the limit is allowed despite rounding
+	dusty := NewInventory("dusty", 1, 0.3)
+	dusty.Resources = NewResourceRegistry()
+	dusty.Resources.Register(ResourceDef{"dust", 0.1, 0.1})
+	if err := dusty.Add("dust", 3, "test"); err != nil || dusty.Room("dust") != 0 {
+		t.Error("Add did not fill inventory exactly", err)
+	}
+
+	if unlimited := NewInventory("hold", math.Inf(1), math.Inf(1)); unlimited.Room("iron") == 0 {
+		t.Error("unlimited inventory has no room")
+	}
+}
+
+func TestInventoryRemove(t *testing.T) {
+	inv := newTestInventory("robot", 100, 100, nil)
+	inv.Add("iron", 3, "test")
+	inv.Add("water", 1, "test")
+
+	if err := inv.Remove("iron", 4, "test"); !errors.Is(err, ErrShortage) || inv.Count("iron") != 3 {
+		t.Error("Remove of more than held did not return ErrShortage", err)
+	}
+	if err := inv.Remove("iron", 3, "test"); err != nil || inv.Count("iron") != 0 {
+		t.Error("Remove did not remove resource", err)
+	}
+	if stacks := inv.Stacks(); len(stacks) != 1 || stacks[0] != (Stack{"water", 1}) {
+		t.Error("Stacks returned", stacks)
+	}
+}
+
+func TestInventoryTransfer(t *testing.T) {
+	l := &Ledger{}
+	robot := newTestInventory("robot", 100, 100, l)
+	base := newTestInventory("base", 10, 100, l)
+	robot.Add("iron", 5, "test")
+	robot.Add("water", 5, "test")
+
+	if err := robot.Transfer(base, "iron", 2, "unload"); err != nil {
+		t.Fatal("Transfer returned error: " + err.Error())
+	}
+	if robot.Count("iron") != 3 || base.Count("iron") != 2 {
+		t.Error("Transfer did not move resource")
+	}
+	if err := robot.Transfer(base, "iron", 1, "unload"); !errors.Is(err, ErrNoRoom) {
+		t.Error("Transfer into full inventory did not return ErrNoRoom", err)
+	}
+	if err := base.Transfer(robot, "water", 1, "load"); !errors.Is(err, ErrShortage) {
+		t.Error("Transfer of resource not held did not return ErrShortage", err)
+	}
+	if robot.Count("iron") != 3 || base.Count("iron") != 2 || robot.Count("water") != 5 {
+		t.Error("failed Transfer changed inventories")
+	}
+
+	// The ledger records every attempt, and balances with the inventories
+	if len(l.Events) != 5 {
+		t.Fatalf("ledger has %d events, expected 5", len(l.Events))
+	}
+	if e := l.Events[2]; e != (LedgerEvent{2, "robot", "base", "iron", 2, "unload", ""}) {
+		t.Errorf("ledger recorded %+v for transfer", e)
+	}
+	if l.Events[3].Err == "" || l.Events[4].Err == "" {
+		t.Error("ledger did not record failures")
+	}
+	if l.Balance("robot", "iron") != 3 || l.Balance("base", "iron") != 2 {
+		t.Error("Balance did not match inventories")
+	}
+	if err := l.Audit(robot, base); err != nil {
+		t.Error("Audit returned error: " + err.Error())
+	}
+
+	// Changes made behind the ledger's back are found
+	base.stacks["iron"]++
+	if l.Audit(robot, base) == nil {
+		t.Error("Audit did not find unrecorded change")
+	}
+
+	var buf bytes.Buffer
+	l.WriteTo(&buf)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || lines[2] != "2: 2 iron robot -> base (unload)" {
+		t.Error("WriteTo wrote", lines)
+	}
+	if !strings.HasSuffix(lines[3], "failed: adding 1 iron to base: not enough room") {
+		t.Error("WriteTo wrote failure as " + lines[3])
+	}
+}
+
+func TestFrameCollector(t *testing.T) {
+	f := NewFrame()
+	f.Registry = NewBlockRegistry()
+	f.Registry.Register(BlockDef{Id: 1, Name: "rock", Solid: true})
+	f.Registry.Register(BlockDef{Id: 3, Name: "iron ore", Solid: true, Yield: "iron", YieldCount: 2})
+	l := &Ledger{}
+	f.Collector = newTestInventory("robot", 12, 100, l)
+
+	f.SetBlock(0, 0, 0, Block{3, 0})
+	f.SetBlock(1, 0, 0, Block{3, 0})
+	f.SetBlock(2, 0, 0, Block{1, 0})
+	if len(l.Events) != 0 {
+		t.Error("placing blocks credited yield")
+	}
+
+	f.SetBlock(0, 0, 0, Block{})
+	if f.Collector.Count("iron") != 2 {
+		t.Error("mining iron ore did not credit yield")
+	}
+	if e := l.Events[0]; e.Reason != "mined iron ore at (0, 0, 0)" || e.To != "robot" {
+		t.Errorf("ledger recorded %+v for mining", e)
+	}
+
+	// Blocks without a yield credit nothing, and replacing a block is not
+	// mining it
+	f.SetBlock(2, 0, 0, Block{})
+	f.SetBlock(1, 0, 0, Block{1, 0})
+	f.SetBlock(1, 0, 0, Block{3, 0})
+	if len(l.Events) != 1 {
+		t.Error("SetBlock credited yield without mining ore")
+	}
+
+	// SetBlock clears blocks whose yield does not fit, but Mine leaves
+	// them in place
+	f.SetBlock(1, 0, 0, Block{})
+	if !f.Block(1, 0, 0).IsEmpty() || f.Collector.Count("iron") != 2 || l.Events[1].Err == "" {
+		t.Error("SetBlock into full inventory did not clear block")
+	}
+	f.SetBlock(1, 0, 0, Block{3, 0})
+	if err := f.Mine(1, 0, 0); !errors.Is(err, ErrNoRoom) || f.Block(1, 0, 0).IsEmpty() {
+		t.Error("Mine into full inventory did not return ErrNoRoom", err)
+	}
+	f.Collector.Remove("iron", 2, "test")
+	if err := f.Mine(1, 0, 0); err != nil || !f.Block(1, 0, 0).IsEmpty() || f.Collector.Count("iron") != 2 {
+		t.Error("Mine did not mine block once there was room", err)
+	}
+
+	// Splitting a frame moves blocks without mining them
+	f.Collector.Remove("iron", 2, "test")
+	f.SetBlock(0, 0, 0, Block{3, 0})
+	f.SetBlock(5, 0, 0, Block{3, 0})
+	f.Split()
+	if f.Collector.Count("iron") != 0 {
+		t.Error("Split credited yield of moved blocks")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// LedgerEvent records one attempted change to an inventory.
+type LedgerEvent struct {
+	Seq      int    // position of the event in the ledger, from zero
+	From, To string // inventory names, empty for outside any inventory
+	Resource string
+	Count    uint
+	Reason   string // what caused the change, such as mining a block
+	Err      string // why the change failed, empty if it was made
+}
+
+// String describes the event on a single line.
+func (e LedgerEvent) String() string {
+	from, to := e.From, e.To
+	if from == "" {
+		from = "-"
+	}
+	if to == "" {
+		to = "-"
+	}
+	s := fmt.Sprintf("%d: %d %s %s -> %s (%s)", e.Seq, e.Count, e.Resource, from, to, e.Reason)
+	if e.Err != "" {
+		s += " failed: " + e.Err
+	}
+	return s
+}
+
+// Ledger is a log of every change made to the inventories which share it,
+// including changes which failed, so that the movement of resources can be
+// audited.
+type Ledger struct {
+	Events []LedgerEvent
+}
+
+// record appends an event to the ledger. A nil ledger records nothing.
+func (l *Ledger) record(from, to, resource string, n uint, reason string, err error) {
+	if l == nil {
+		return
+	}
+	e := LedgerEvent{len(l.Events), from, to, resource, n, reason, ""}
+	if err != nil {
+		e.Err = err.Error()
+	}
+	l.Events = append(l.Events, e)
+}
+
+// Balance returns the net amount of the named resource moved into the
+// named inventory by the successful events in the ledger.
+func (l *Ledger) Balance(name, resource string) (n int) {
+	for _, e := range l.Events {
+		if e.Err != "" || e.Resource != resource || e.From == e.To {
+			continue
+		}
+		if e.To == name {
+			n += int(e.Count)
+		}
+		if e.From == name {
+			n -= int(e.Count)
+		}
+	}
+	return
+}
+
+// Audit checks that the contents of each inventory match the ledger,
+// assuming they started empty and were only changed through it.
+func (l *Ledger) Audit(invs ...*Inventory) error {
+	for _, inv := range invs {
+		seen := make(map[string]bool)
+		for _, e := range l.Events {
+			seen[e.Resource] = true
+		}
+		for r := range inv.stacks {
+			seen[r] = true
+		}
+		for r := range seen {
+			if b := l.Balance(inv.Name, r); b != int(inv.Count(r)) {
+				return fmt.Errorf("%s holds %d %s, but ledger balance is %d", inv.Name, inv.Count(r), r, b)
+			}
+		}
+	}
+	return nil
+}
+
+// WriteTo writes the events in the ledger to w, one per line.
+func (l *Ledger) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, e := range l.Events {
+		n, err := fmt.Fprintln(w, e)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
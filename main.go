@@ -1,73 +1,138 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
+	"math"
+	"os"
 
+	"github.com/3SillyHats/DarkLogic/generate"
 	"github.com/go-gl/gl"
 	"github.com/go-gl/glfw"
 )
 
 func main() {
-	glfw.Init()
+	flag.Var(&GLCheck, "glcheck", "what to do with OpenGL errors: return, panic or skip")
+	seed := flag.Int64("seed", 1, "seed for generating the world")
+	flag.Parse()
+	if e := run(*seed); e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		os.Exit(1)
+	}
+}
+
+// run opens the window and runs the game in a world generated from seed
+// until it is closed.
+func run(seed int64) error {
+	if e := glfw.Init(); e != nil {
+		return e
+	}
 	defer glfw.Terminate()
 
 	glfw.OpenWindowHint(glfw.OpenGLVersionMajor, 3)
 	glfw.OpenWindowHint(glfw.OpenGLVersionMinor, 1)
 	//glfw.OpenWindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.OpenWindow(800, 600, 0, 0, 0, 0, 16, 0, glfw.Windowed)
+	if e := glfw.OpenWindow(800, 600, 0, 0, 0, 0, 16, 0, glfw.Windowed); e != nil {
+		return e
+	}
 	defer glfw.CloseWindow()
 
-	gl.Init()
+	if gl.Init() != 0 {
+		return errors.New("initialising OpenGL failed")
+	}
 	gl.ClearColor(0.2, 0.2, 0.2, 0.0)
 	
-	// Create shaders
-	vs := gl.CreateShader(gl.VERTEX_SHADER)
-	vs_source, _ := ioutil.ReadFile("basic.vs")
-	vs.Source(string(vs_source))
-	vs.Compile()
-	fmt.Println(vs.GetInfoLog())
-	
-	fs := gl.CreateShader(gl.FRAGMENT_SHADER)
-	fs_source, _ := ioutil.ReadFile("basic.fs")
-	fs.Source(string(fs_source))
-	fs.Compile()
-	fmt.Println(fs.GetInfoLog())
-	
-	// Create shader program
-	program := gl.CreateProgram()
-	program.AttachShader(vs)
-	program.AttachShader(fs)
-	program.Validate()
-	program.Link()
-	program.Use()
-	fmt.Println(program.GetInfoLog())
-	
-	// Setup uniforms
-	
-	var projMat Matrix4
-	projMat.LoadOrthographic(-2, 2, -2, 2, -5, 5)
-	a := projMat.Array()
-	projLoc := program.GetUniformLocation("projection_matrix")
-	projLoc.UniformMatrix4f(false, &a)
-	sqt := NewSQT()
-	mvLoc := program.GetUniformLocation("modelview_matrix")
-	mat := sqt.Matrix()
-	mvLoc.UniformMatrix4f(false, &mat)
-	err()
-	
-	// Load model
-	model := NewModel(program)
+	// Load shaders
+	shader, e := LoadShaderProgram(os.DirFS("."), "basic", nil)
+	if e != nil {
+		return e
+	}
+
+	renderer := NewGLRenderer(shader)
+	caches := make(map[*Frame]*MeshCache)
 
-	for glfw.WindowParam(glfw.Opened) > 0 {
-		// Input
-		if glfw.Key(glfw.KeyEsc) == glfw.KeyPress {
-			glfw.CloseWindow()
+	// Input
+	bindings, e := LoadBindingsFile("bindings.json")
+	if e != nil {
+		fmt.Println(e)
+		bindings = DefaultBindings()
+	}
+	glfw.Disable(glfw.MouseCursor)
+
+	game := NewGame(1.0/60.0, NewActions(&GLFWInput{}, bindings))
+	game.Camera.Aspect = 800.0 / 600.0
+	game.Camera.Transform.SetTranslation(Vec3{0.5, 30, 0.5})
+	if e := DefaultBlocks.LoadFile("blocks.json"); e != nil {
+		return e
+	}
+	ores, e := LoadOreRulesFile("ores.json", DefaultBlocks)
+	if e != nil {
+		return e
+	}
+	world := NewWorld(NewFrame(), generate.NewCaves(seed+1, generate.NewTerrain(seed)))
+	world.Ores = &OrePass{ores, seed + 2}
+	if e := DefaultResources.LoadFile("resources.json"); e != nil {
+		return e
+	}
+	parts, e := LoadPartsFile("parts.json", DefaultBlocks)
+	if e != nil {
+		return e
+	}
+	body := NewFrame()
+	for i, name := range []string{"hull", "thruster", "battery", "drill", "sensor", "cargo hold", "wire", "solar panel"} {
+		def, _ := DefaultBlocks.ByName(name)
+		body.SetBlock(i, 0, 0, Block{def.Id, 0})
+	}
+	robot := NewRobot(body, parts)
+	robot.Cargo.Ledger = &Ledger{}
+	world.Frame.Collector = robot.Cargo
+	game.Power = append(game.Power, NewPowerGrid(body, parts))
+	game.Frames = append(game.Frames, world.Frame)
+	last := glfw.Time()
+	nextReload := last + 1
+
+	for glfw.WindowParam(glfw.Opened) > 0 && !game.Done {
+		now := glfw.Time()
+		alpha := game.Advance(now - last)
+		last = now
+
+		// Generate the world around the player as they explore
+		c := game.Camera.Transform.Translation()
+		world.GenerateAround(int(math.Floor(c.X)), int(math.Floor(c.Y)), int(math.Floor(c.Z)), 1)
+
+		// Pick up edited shaders about once a second
+		if now >= nextReload {
+			if _, e := shader.ReloadIfChanged(); e != nil {
+				fmt.Println(e)
+			}
+			nextReload = now + 1
 		}
 
 		// Rendering
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-		model.Render()
+		proj := game.Camera.Projection()
+		renderer.Begin(&proj)
+		var uploadErr error
+		game.Render(alpha, func(f *Frame, modelview *SQT) {
+			cache, ok := caches[f]
+			if !ok {
+				cache = NewMeshCache(f, renderer)
+				caches[f] = cache
+			}
+			if _, e := cache.Update(); e != nil && uploadErr == nil {
+				uploadErr = e
+			}
+			var mv Matrix4
+			mv.LoadSQT(modelview)
+			cache.Draw(renderer, &mv)
+		})
+		if e := renderer.End(); e != nil {
+			return e
+		}
+		if uploadErr != nil {
+			return uploadErr
+		}
 		glfw.SwapBuffers()
 	}
+	return nil
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+)
+
+// minMass is the smallest mass treated as any mass at all. The mass of a
+// frame is a running sum, so it can be left slightly off zero by rounding.
+const minMass = 1e-9
+
+// massProps accumulates the mass distribution of a frame's voxels, so that
+// it can be updated incrementally as blocks change.
+type massProps struct {
+	mass   float64       // total mass
+	moment [3]float64    // sum of mass times voxel centre
+	second [3][3]float64 // sum of mass times outer product of voxel centre
+}
+
+// update replaces the contribution of Block old at local voxel coordinates
+// (x, y, z) with that of Block b.
+func (m *massProps) update(reg *BlockRegistry, x, y, z int, old, b Block) {
+	if old == b {
+		return
+	}
+	c := [3]float64{float64(x) + 0.5, float64(y) + 0.5, float64(z) + 0.5}
+	if !old.IsEmpty() {
+		m.add(c, -reg.Def(old.Id).Density)
+	}
+	if !b.IsEmpty() {
+		m.add(c, reg.Def(b.Id).Density)
+	}
+}
+
+func (m *massProps) add(c [3]float64, mass float64) {
+	m.mass += mass
+	for i := 0; i < 3; i++ {
+		m.moment[i] += mass * c[i]
+		for j := 0; j < 3; j++ {
+			m.second[i][j] += mass * c[i] * c[j]
+		}
+	}
+}
+
+// RecomputeMass recalculates the frame's mass properties from scratch. It
+// is only needed after changing the densities of blocks already in the
+// frame, such as by changing its Registry; SetBlock keeps them up to date.
+func (f *Frame) RecomputeMass() {
+	f.mass = massProps{}
+	reg := f.registry()
+	for _, p := range f.chunkPositions() {
+		c := f.chunks[p]
+		for i := 0; i < ncx; i++ {
+			for j := 0; j < ncy; j++ {
+				for k := 0; k < ncz; k++ {
+					f.mass.update(reg, p.x*ncx+i, p.y*ncy+j, p.z*ncz+k, Block{}, c[i][j][k])
+				}
+			}
+		}
+	}
+}
+
+// Mass returns the total mass of the frame's voxels, using the densities
+// in its block registry.
+func (f *Frame) Mass() float64 {
+	return f.mass.mass
+}
+
+// CentreOfMass returns the centre of mass of the frame in its local
+// coordinates, or the origin if it has no mass.
+func (f *Frame) CentreOfMass() Vec3 {
+	m := f.mass.mass
+	if m < minMass {
+		return Vec3{}
+	}
+	return Vec3{f.mass.moment[0] / m, f.mass.moment[1] / m, f.mass.moment[2] / m}
+}
+
+// Inertia returns the inertia tensor of the frame about its centre of
+// mass, in its local coordinates and unscaled by its Transform. Each voxel
+// is treated as a solid unit cube.
+func (f *Frame) Inertia() (t [3][3]float64) {
+	m := f.mass
+	if m.mass < minMass {
+		return
+	}
+	c := f.CentreOfMass()
+	com := [3]float64{c.X, c.Y, c.Z}
+
+	// Second moment about the centre of mass, by the parallel axis theorem
+	var s [3][3]float64
+	trace := 0.0
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			s[i][j] = m.second[i][j] - m.mass*com[i]*com[j]
+		}
+		trace += s[i][i]
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			t[i][j] = -s[i][j]
+		}
+		t[i][i] += trace + m.mass/6
+	}
+	return
+}
+
+// inverse3 returns the inverse of the 3x3 matrix m, and false if it is
+// singular.
+func inverse3(m [3][3]float64) (inv [3][3]float64, ok bool) {
+	inv[0][0] = m[1][1]*m[2][2] - m[1][2]*m[2][1]
+	inv[0][1] = m[0][2]*m[2][1] - m[0][1]*m[2][2]
+	inv[0][2] = m[0][1]*m[1][2] - m[0][2]*m[1][1]
+	inv[1][0] = m[1][2]*m[2][0] - m[1][0]*m[2][2]
+	inv[1][1] = m[0][0]*m[2][2] - m[0][2]*m[2][0]
+	inv[1][2] = m[0][2]*m[1][0] - m[0][0]*m[1][2]
+	inv[2][0] = m[1][0]*m[2][1] - m[1][1]*m[2][0]
+	inv[2][1] = m[0][1]*m[2][0] - m[0][0]*m[2][1]
+	inv[2][2] = m[0][0]*m[1][1] - m[0][1]*m[1][0]
+
+	det := m[0][0]*inv[0][0] + m[0][1]*inv[1][0] + m[0][2]*inv[2][0]
+	if det == 0 || math.IsNaN(det) {
+		return inv, false
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			inv[i][j] /= det
+		}
+	}
+	return inv, true
+}
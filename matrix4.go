@@ -81,3 +81,220 @@ func (m *Matrix4) LoadPerspective(fov, aspect, near, far float32) {
 	m[3][1] = 0.0
 	m[3][3] = 0.0
 }
+
+// LoadArray replaces the current matrix with the matrix stored row by row
+// in a, as returned by Array and SQT.Matrix.
+func (m *Matrix4) LoadArray(a [16]float32) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			m[i][j] = a[i*4+j]
+		}
+	}
+}
+
+// LoadSQT replaces the current matrix with the affine transformation
+// matrix of the SQT transformation s.
+func (m *Matrix4) LoadSQT(s *SQT) {
+	m.LoadArray(s.Matrix())
+}
+
+// LoadLookAt replaces the current matrix with a view matrix for a camera
+// at eye looking towards centre, with up giving the upward direction.
+func (m *Matrix4) LoadLookAt(eye, centre, up Vec3) {
+	f := centre.Sub(eye).Normalise()
+	s := f.Cross(up).Normalise()
+	u := s.Cross(f)
+
+	*m = Matrix4{
+		{float32(s.X), float32(s.Y), float32(s.Z), float32(-s.Dot(eye))},
+		{float32(u.X), float32(u.Y), float32(u.Z), float32(-u.Dot(eye))},
+		{float32(-f.X), float32(-f.Y), float32(-f.Z), float32(f.Dot(eye))},
+		{0, 0, 0, 1},
+	}
+}
+
+// Multiply returns the product of the matrices m and n, which applies n
+// and then m.
+func (m *Matrix4) Multiply(n *Matrix4) (o Matrix4) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				o[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return
+}
+
+// Transpose returns the transpose of the matrix.
+func (m *Matrix4) Transpose() (o Matrix4) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			o[i][j] = m[j][i]
+		}
+	}
+	return
+}
+
+// Determinant returns the determinant of the matrix.
+func (m *Matrix4) Determinant() float32 {
+	_, det := m.adjugate()
+	return float32(det)
+}
+
+// Inverse returns the inverse of the matrix, and false if the matrix is
+// singular.
+func (m *Matrix4) Inverse() (o Matrix4, ok bool) {
+	adj, det := m.adjugate()
+	if det == 0 {
+		return o, false
+	}
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			o[i][j] = float32(adj[i][j] / det)
+		}
+	}
+	return o, true
+}
+
+// adjugate returns the adjugate and the determinant of the matrix,
+// calculated in double precision.
+func (m *Matrix4) adjugate() (adj [4][4]float64, det float64) {
+	var a [4][4]float64
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			a[i][j] = float64(m[i][j])
+		}
+	}
+
+	// 2x2 minors of the top and bottom two rows
+	s0 := a[0][0]*a[1][1] - a[1][0]*a[0][1]
+	s1 := a[0][0]*a[1][2] - a[1][0]*a[0][2]
+	s2 := a[0][0]*a[1][3] - a[1][0]*a[0][3]
+	s3 := a[0][1]*a[1][2] - a[1][1]*a[0][2]
+	s4 := a[0][1]*a[1][3] - a[1][1]*a[0][3]
+	s5 := a[0][2]*a[1][3] - a[1][2]*a[0][3]
+	c5 := a[2][2]*a[3][3] - a[3][2]*a[2][3]
+	c4 := a[2][1]*a[3][3] - a[3][1]*a[2][3]
+	c3 := a[2][1]*a[3][2] - a[3][1]*a[2][2]
+	c2 := a[2][0]*a[3][3] - a[3][0]*a[2][3]
+	c1 := a[2][0]*a[3][2] - a[3][0]*a[2][2]
+	c0 := a[2][0]*a[3][1] - a[3][0]*a[2][1]
+
+	det = s0*c5 - s1*c4 + s2*c3 + s3*c2 - s4*c1 + s5*c0
+
+	adj[0][0] = a[1][1]*c5 - a[1][2]*c4 + a[1][3]*c3
+	adj[0][1] = -a[0][1]*c5 + a[0][2]*c4 - a[0][3]*c3
+	adj[0][2] = a[3][1]*s5 - a[3][2]*s4 + a[3][3]*s3
+	adj[0][3] = -a[2][1]*s5 + a[2][2]*s4 - a[2][3]*s3
+	adj[1][0] = -a[1][0]*c5 + a[1][2]*c2 - a[1][3]*c1
+	adj[1][1] = a[0][0]*c5 - a[0][2]*c2 + a[0][3]*c1
+	adj[1][2] = -a[3][0]*s5 + a[3][2]*s2 - a[3][3]*s1
+	adj[1][3] = a[2][0]*s5 - a[2][2]*s2 + a[2][3]*s1
+	adj[2][0] = a[1][0]*c4 - a[1][1]*c2 + a[1][3]*c0
+	adj[2][1] = -a[0][0]*c4 + a[0][1]*c2 - a[0][3]*c0
+	adj[2][2] = a[3][0]*s4 - a[3][1]*s2 + a[3][3]*s0
+	adj[2][3] = -a[2][0]*s4 + a[2][1]*s2 - a[2][3]*s0
+	adj[3][0] = -a[1][0]*c3 + a[1][1]*c1 - a[1][2]*c0
+	adj[3][1] = a[0][0]*c3 - a[0][1]*c1 + a[0][2]*c0
+	adj[3][2] = -a[3][0]*s3 + a[3][1]*s1 - a[3][2]*s0
+	adj[3][3] = a[2][0]*s3 - a[2][1]*s1 + a[2][2]*s0
+	return
+}
+
+// Transform returns the product of the matrix and the homogeneous vector v.
+func (m *Matrix4) Transform(v [4]float32) (o [4]float32) {
+	for i := 0; i < 4; i++ {
+		o[i] = m[i][0]*v[0] + m[i][1]*v[1] + m[i][2]*v[2] + m[i][3]*v[3]
+	}
+	return
+}
+
+// TransformPoint applies the matrix to the point v, dividing through by the
+// resulting w coordinate.
+func (m *Matrix4) TransformPoint(v Vec3) Vec3 {
+	o := m.Transform([4]float32{float32(v.X), float32(v.Y), float32(v.Z), 1})
+	return Vec3{float64(o[0] / o[3]), float64(o[1] / o[3]), float64(o[2] / o[3])}
+}
+
+// TransformVector applies the matrix to the direction v, ignoring any
+// translation.
+func (m *Matrix4) TransformVector(v Vec3) Vec3 {
+	o := m.Transform([4]float32{float32(v.X), float32(v.Y), float32(v.Z), 0})
+	return Vec3{float64(o[0]), float64(o[1]), float64(o[2])}
+}
+
+// SQT returns the SQT transformation represented by the matrix, and false
+// if the matrix is not an affine transformation made of a uniform scale, a
+// rotation and a translation.
+func (m *Matrix4) SQT() (s *SQT, ok bool) {
+	if m[3][0] != 0 || m[3][1] != 0 || m[3][2] != 0 || m[3][3] != 1 {
+		return nil, false
+	}
+
+	var r [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i][j] = float64(m[i][j])
+		}
+	}
+	det := r[0][0]*(r[1][1]*r[2][2]-r[1][2]*r[2][1]) -
+		r[0][1]*(r[1][0]*r[2][2]-r[1][2]*r[2][0]) +
+		r[0][2]*(r[1][0]*r[2][1]-r[1][1]*r[2][0])
+	if det == 0 {
+		return nil, false
+	}
+	scale := math.Cbrt(det)
+
+	// What remains must be a rotation
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i][j] /= scale
+		}
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			dot := r[0][i]*r[0][j] + r[1][i]*r[1][j] + r[2][i]*r[2][j]
+			if i == j {
+				dot--
+			}
+			if math.Abs(dot) > 1e-4 {
+				return nil, false
+			}
+		}
+	}
+
+	s = NewSQT()
+	s.scale = scale
+	s.t = Vec3{float64(m[0][3]), float64(m[1][3]), float64(m[2][3])}
+
+	// Convert the rotation matrix to a quaternion, pivoting on the largest
+	// diagonal element for stability
+	switch trace := r[0][0] + r[1][1] + r[2][2]; {
+	case trace > 0:
+		q := 2 * math.Sqrt(1+trace)
+		s.q.W = q / 4
+		s.q.X = (r[2][1] - r[1][2]) / q
+		s.q.Y = (r[0][2] - r[2][0]) / q
+		s.q.Z = (r[1][0] - r[0][1]) / q
+	case r[0][0] > r[1][1] && r[0][0] > r[2][2]:
+		q := 2 * math.Sqrt(1+r[0][0]-r[1][1]-r[2][2])
+		s.q.W = (r[2][1] - r[1][2]) / q
+		s.q.X = q / 4
+		s.q.Y = (r[0][1] + r[1][0]) / q
+		s.q.Z = (r[0][2] + r[2][0]) / q
+	case r[1][1] > r[2][2]:
+		q := 2 * math.Sqrt(1+r[1][1]-r[0][0]-r[2][2])
+		s.q.W = (r[0][2] - r[2][0]) / q
+		s.q.X = (r[0][1] + r[1][0]) / q
+		s.q.Y = q / 4
+		s.q.Z = (r[1][2] + r[2][1]) / q
+	default:
+		q := 2 * math.Sqrt(1+r[2][2]-r[0][0]-r[1][1])
+		s.q.W = (r[1][0] - r[0][1]) / q
+		s.q.X = (r[0][2] + r[2][0]) / q
+		s.q.Y = (r[1][2] + r[2][1]) / q
+		s.q.Z = q / 4
+	}
+	return s, true
+}
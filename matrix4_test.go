@@ -0,0 +1,209 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMultiply(t *testing.T) {
+	a := Matrix4{
+		{1, 2, 3, 4},
+		{5, 6, 7, 8},
+		{9, 10, 11, 12},
+		{13, 14, 15, 16},
+	}
+	var b Matrix4
+	b.LoadIdentity()
+	if a.Multiply(&b) != a || b.Multiply(&a) != a {
+		t.Error("Multiply by identity did not return the same matrix")
+	}
+
+	b = Matrix4{
+		{0, 1, 0, 0},
+		{1, 0, 0, 0},
+		{0, 0, 2, 0},
+		{0, 0, 0, 1},
+	}
+	expected := Matrix4{
+		{2, 1, 6, 4},
+		{6, 5, 14, 8},
+		{10, 9, 22, 12},
+		{14, 13, 30, 16},
+	}
+	if a.Multiply(&b) != expected {
+		t.Error("Multiply did not return expected product")
+	}
+
+	expected = Matrix4{
+		{1, 5, 9, 13},
+		{2, 6, 10, 14},
+		{3, 7, 11, 15},
+		{4, 8, 12, 16},
+	}
+	if a.Transpose() != expected {
+		t.Error("Transpose did not return expected matrix")
+	}
+}
+
+func TestInverse4(t *testing.T) {
+	a := Matrix4{
+		{2, -1, 0, 3},
+		{1, 3, 2, -1},
+		{0, 1, 4, 2},
+		{1, 0, -2, 5},
+	}
+	inv, ok := a.Inverse()
+	if !ok {
+		t.Fatal("Inverse returned singular for invertible matrix")
+	}
+	var identity Matrix4
+	identity.LoadIdentity()
+	p := a.Multiply(&inv)
+	if matrixDiff(&p, &identity) > 1e-5 {
+		t.Error("Inverse did not return inverse", p)
+	}
+	p = inv.Multiply(&a)
+	if matrixDiff(&p, &identity) > 1e-5 {
+		t.Error("Inverse did not return inverse on the left", p)
+	}
+
+	if d := a.Determinant(); math.Abs(float64(d)-determinant(&a)) > 1e-3 {
+		t.Errorf("Determinant returned %v, expected %v", d, determinant(&a))
+	}
+
+	singular := Matrix4{
+		{1, 2, 3, 4},
+		{2, 4, 6, 8},
+		{0, 1, 0, 1},
+		{1, 0, 1, 0},
+	}
+	if _, ok := singular.Inverse(); ok {
+		t.Error("Inverse did not report singular matrix")
+	}
+	if singular.Determinant() != 0 {
+		t.Error("Determinant did not return zero for singular matrix")
+	}
+}
+
+func TestTransformPoint(t *testing.T) {
+	var m Matrix4
+	s := NewSQT()
+	s.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{0, 0, 1}))
+	s.SetTranslation(Vec3{1, 2, 3})
+	s.SetScale(2)
+	m.LoadSQT(s)
+
+	checkPoint32(t, "TransformPoint", m.TransformPoint(Vec3{1, 0, 0}), s.TransformAbs(Vec3{1, 0, 0}))
+	checkPoint32(t, "TransformVector", m.TransformVector(Vec3{1, 0, 0}), s.TransformRel(Vec3{1, 0, 0}))
+
+	// Perspective divide: a point on the near plane maps to depth -1
+	m.LoadPerspective(math.Pi/2, 1, 1, 10)
+	checkPoint32(t, "TransformPoint with perspective", m.TransformPoint(Vec3{1, 1, -1}), Vec3{1, 1, -1})
+}
+
+func TestLookAt(t *testing.T) {
+	var m Matrix4
+	m.LoadLookAt(Vec3{0, 0, 5}, Vec3{0, 0, 0}, Vec3{0, 1, 0})
+	checkPoint32(t, "LookAt target", m.TransformPoint(Vec3{0, 0, 0}), Vec3{0, 0, -5})
+
+	m.LoadLookAt(Vec3{1, 2, 3}, Vec3{1, 2, 2}, Vec3{0, 1, 0})
+	var identity Matrix4
+	identity.LoadIdentity()
+	identity[0][3], identity[1][3], identity[2][3] = -1, -2, -3
+	if matrixDiff(&m, &identity) > 1e-6 {
+		t.Error("LookAt down -z did not return translation", m)
+	}
+
+	m.LoadLookAt(Vec3{0, 0, 0}, Vec3{1, 0, 0}, Vec3{0, 0, 1})
+	checkPoint32(t, "LookAt along x", m.TransformPoint(Vec3{2, 0, 1}), Vec3{0, 1, -2})
+}
+
+func TestMatrixSQT(t *testing.T) {
+	cases := []*SQT{NewSQT()}
+	for _, r := range [][4]float64{
+		{math.Pi / 2, 1, 0, 0},
+		{math.Pi, 0, 1, 0},
+		{2.5, 0, 0.6, -0.8},
+		{-1, 0.48, 0.6, 0.64},
+		{3, 0, 0, 1},
+	} {
+		s := NewSQT()
+		s.SetRotation(QuatAxisAngle(r[0], Vec3{r[1], r[2], r[3]}))
+		s.SetTranslation(Vec3{r[1] * 4, -r[0], 7})
+		s.SetScale(r[0])
+		cases = append(cases, s)
+	}
+
+	for _, s := range cases {
+		var m Matrix4
+		m.LoadSQT(s)
+		o, ok := m.SQT()
+		if !ok {
+			t.Error("SQT did not accept matrix of SQT transformation")
+			continue
+		}
+		var n Matrix4
+		n.LoadSQT(o)
+		if matrixDiff(&m, &n) > 1e-5 {
+			t.Error("SQT did not round trip through Matrix4", m, n)
+		}
+	}
+
+	shear := Matrix4{
+		{1, 1, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+	if _, ok := shear.SQT(); ok {
+		t.Error("SQT accepted shear matrix")
+	}
+	var p Matrix4
+	p.LoadPerspective(1, 1, 1, 10)
+	if _, ok := p.SQT(); ok {
+		t.Error("SQT accepted perspective matrix")
+	}
+}
+
+// checkPoint32 checks a point calculated in single precision.
+func checkPoint32(t *testing.T, desc string, p, expected Vec3) {
+	if p.Sub(expected).Length() > 1e-6 {
+		t.Errorf("%s returned %v, expected %v", desc, p, expected)
+	}
+}
+
+func matrixDiff(a, b *Matrix4) (d float32) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if e := float32(math.Abs(float64(a[i][j] - b[i][j]))); e > d {
+				d = e
+			}
+		}
+	}
+	return
+}
+
+// determinant calculates the determinant by cofactor expansion along the
+// first row, to check Determinant against.
+func determinant(m *Matrix4) (det float64) {
+	for c := 0; c < 4; c++ {
+		var minor [3][3]float64
+		for i := 1; i < 4; i++ {
+			k := 0
+			for j := 0; j < 4; j++ {
+				if j != c {
+					minor[i-1][k] = float64(m[i][j])
+					k++
+				}
+			}
+		}
+		d := minor[0][0]*(minor[1][1]*minor[2][2]-minor[1][2]*minor[2][1]) -
+			minor[0][1]*(minor[1][0]*minor[2][2]-minor[1][2]*minor[2][0]) +
+			minor[0][2]*(minor[1][0]*minor[2][1]-minor[1][1]*minor[2][0])
+		if c%2 == 1 {
+			d = -d
+		}
+		det += float64(m[0][c]) * d
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math"
+)
+
+// mergeTolerance is how far, in voxels, a merged block's centre may be from
+// the centre of a voxel and still count as aligned with it.
+const mergeTolerance = 1e-6
+
+// MergeMiss describes a block of another frame that Merge could not place
+// exactly.
+type MergeMiss struct {
+	X, Y, Z  int     // local voxel coordinates in the other frame
+	Offset   float64 // distance from the block's centre to the centre of the voxel it was placed in
+	Occupied bool    // the voxel was already filled, so the block was not placed
+}
+
+// Merge copies the blocks of other into the local grid of f, mapping each
+// block's centre through the relative transform of the frames and placing
+// it in the voxel containing it. Rotations by multiples of 90 degrees with
+// whole voxel translations and equal scales align exactly; otherwise blocks
+// are snapped to the nearest voxel and reported as misses along with the
+// offset. Blocks which land on a filled voxel are not placed and are
+// reported as occupied. other is not modified.
+func (f *Frame) Merge(other *Frame) (misses []MergeMiss) {
+	rel := other.TransformTo(f)
+
+	for _, p := range other.chunkPositions() {
+		c := other.chunks[p]
+		for i := 0; i < ncx; i++ {
+			for j := 0; j < ncy; j++ {
+				for k := 0; k < ncz; k++ {
+					b := c[i][j][k]
+					if b.IsEmpty() {
+						continue
+					}
+					x, y, z := p.x*ncx+i, p.y*ncy+j, p.z*ncz+k
+
+					centre := rel.TransformAbs(Vec3{float64(x) + 0.5, float64(y) + 0.5, float64(z) + 0.5})
+					tx, ty, tz := math.Floor(centre.X), math.Floor(centre.Y), math.Floor(centre.Z)
+					offset := centre.Sub(Vec3{tx + 0.5, ty + 0.5, tz + 0.5}).Length()
+					miss := MergeMiss{X: x, Y: y, Z: z, Offset: offset}
+
+					if !f.Block(int(tx), int(ty), int(tz)).IsEmpty() {
+						miss.Occupied = true
+						misses = append(misses, miss)
+						continue
+					}
+					f.SetBlock(int(tx), int(ty), int(tz), b)
+					if miss.Offset > mergeTolerance {
+						misses = append(misses, miss)
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
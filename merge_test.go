@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMergeAligned(t *testing.T) {
+	rotations := []struct {
+		theta, x, y, z float64
+	}{
+		{0, 1, 0, 0},
+		{math.Pi / 2, 1, 0, 0},
+		{math.Pi, 0, 1, 0},
+		{3 * math.Pi / 2, 0, 0, 1},
+		{-math.Pi / 2, 0, 0, 1},
+	}
+
+	for _, r := range rotations {
+		f := NewFrame()
+		f.Transform.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{0, 1, 0}))
+		f.Transform.SetTranslation(Vec3{0.5, 0, 0})
+
+		g := NewFrame()
+		*g.Transform = *f.Transform
+		g.Transform.Rotate(QuatAxisAngle(r.theta, Vec3{r.x, r.y, r.z}))
+		g.Transform.Translate(Vec3{3, -17, 0})
+
+		blocks := [][3]int{{0, 0, 0}, {-1, 0, 0}, {5, -20, 3}, {15, 16, -16}}
+		for i, v := range blocks {
+			g.SetBlock(v[0], v[1], v[2], Block{uint(i + 1), 0})
+		}
+
+		if misses := f.Merge(g); len(misses) != 0 {
+			t.Errorf("Merge returned misses %v for rotation by %v about (%v, %v, %v)", misses, r.theta, r.x, r.y, r.z)
+		}
+		for i, v := range blocks {
+			c := g.PointTo(f, Vec3{float64(v[0]) + 0.5, float64(v[1]) + 0.5, float64(v[2]) + 0.5})
+			if b := f.Block(int(math.Floor(c.X)), int(math.Floor(c.Y)), int(math.Floor(c.Z))); b.Id != uint(i+1) {
+				t.Errorf("Merge did not place block %v for rotation by %v about (%v, %v, %v)", v, r.theta, r.x, r.y, r.z)
+			}
+		}
+		if f.Mass() != float64(len(blocks)) {
+			t.Errorf("Merge produced mass %v, expected %d", f.Mass(), len(blocks))
+		}
+		if g.Mass() != float64(len(blocks)) {
+			t.Error("Merge modified other frame")
+		}
+	}
+}
+
+func TestMergeMisaligned(t *testing.T) {
+	f := NewFrame()
+	g := NewFrame()
+	g.Transform.SetRotation(QuatAxisAngle(math.Pi/4, Vec3{0, 0, 1}))
+	for x := 0; x < 4; x++ {
+		g.SetBlock(x, 0, 0, Block{1, 0})
+	}
+
+	misses := f.Merge(g)
+	if len(misses) == 0 {
+		t.Fatal("Merge did not report misaligned blocks for rotation by pi/4")
+	}
+	for _, m := range misses {
+		if m.Offset <= mergeTolerance || m.Offset > math.Sqrt(3)/2 {
+			t.Errorf("Merge returned offset %v for misaligned block", m.Offset)
+		}
+		if m.Occupied {
+			t.Error("Merge reported misaligned block as occupied")
+		}
+	}
+	if f.Mass() != 4-float64(countOccupied(misses)) {
+		t.Error("Merge did not place misaligned blocks")
+	}
+}
+
+func TestMergeOccupied(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(1, 0, 0, Block{1, 0})
+	g := NewFrame()
+	g.Transform.SetTranslation(Vec3{1, 0, 0})
+	g.SetBlock(0, 0, 0, Block{2, 0})
+	g.SetBlock(1, 0, 0, Block{2, 0})
+
+	misses := f.Merge(g)
+	if len(misses) != 1 || !misses[0].Occupied || misses[0].X != 0 {
+		t.Fatalf("Merge returned misses %v, expected block (0, 0, 0) occupied", misses)
+	}
+	if f.Block(1, 0, 0).Id != 1 || f.Block(2, 0, 0).Id != 2 {
+		t.Error("Merge overwrote occupied voxel or did not place free block")
+	}
+}
+
+func countOccupied(misses []MergeMiss) (n int) {
+	for _, m := range misses {
+		if m.Occupied {
+			n++
+		}
+	}
+	return
+}
@@ -0,0 +1,166 @@
+package main
+
+// Mesh represents the visible surface of a chunk as indexed triangles,
+// with per-vertex normal and block Id attributes. It does not depend on
+// OpenGL, so it can be built and inspected without a window.
+type Mesh struct {
+	Vertices []float32 // x, y, z for each vertex
+	Normals  []float32 // x, y, z for each vertex
+	Ids      []uint32  // block Id for each vertex
+	Indices  []uint32  // three per triangle, two triangles per quad
+}
+
+// faceNormals lists the directions of the six faces of a voxel, in the
+// order neighbouring chunks are passed to buildMesh.
+var faceNormals = [6][3]int{
+	{-1, 0, 0}, {1, 0, 0},
+	{0, -1, 0}, {0, 1, 0},
+	{0, 0, -1}, {0, 0, 1},
+}
+
+var chunkDims = [3]int{ncx, ncy, ncz}
+
+// Mesh builds the mesh of the chunk at chunk position p, culling faces
+// against the neighbouring chunks of the frame. Vertex positions are in
+// the local coordinates of the frame.
+func (f *Frame) Mesh(p pos) *Mesh {
+	c := f.chunks[p]
+	var neighbours [6]*chunk
+	for i, n := range faceNormals {
+		if nc, ok := f.chunks[pos{p.x + n[0], p.y + n[1], p.z + n[2]}]; ok {
+			neighbours[i] = &nc
+		}
+	}
+	origin := [3]int{p.x * ncx, p.y * ncy, p.z * ncz}
+	return buildMesh(&c, &neighbours, origin, f.registry())
+}
+
+// buildMesh creates a mesh of the visible faces of the chunk c, merging
+// adjacent coplanar faces with the same block Id into larger quads. A face
+// is visible when the voxel on the other side of it is empty, or is a
+// transparent block of a different Id according to reg; voxels outside c
+// are looked up in the neighbouring chunks n (ordered as faceNormals),
+// where a nil chunk is treated as empty. Vertex positions are offset by
+// origin.
+func buildMesh(c *chunk, n *[6]*chunk, origin [3]int, reg *BlockRegistry) *Mesh {
+	m := &Mesh{}
+
+	for d := 0; d < 3; d++ {
+		u, v := (d+1)%3, (d+2)%3
+		du, dv := chunkDims[u], chunkDims[v]
+		mask := make([]uint, du*dv)
+
+		for side := 0; side < 2; side++ {
+			dir := 2*side - 1
+			for s := 0; s < chunkDims[d]; s++ {
+				// Find the visible faces in this slice
+				var x [3]int
+				x[d] = s
+				for j := 0; j < dv; j++ {
+					for i := 0; i < du; i++ {
+						x[u], x[v] = i, j
+						mask[i+j*du] = 0
+						b := c[x[0]][x[1]][x[2]]
+						if b.IsEmpty() {
+							continue
+						}
+						y := x
+						y[d] += dir
+						nb := lookup(c, n, y)
+						if nb.IsEmpty() || nb.Id != b.Id && reg.Def(nb.Id).Transparent {
+							mask[i+j*du] = b.Id
+						}
+					}
+				}
+
+				// Greedily merge them into rectangles
+				for j := 0; j < dv; j++ {
+					for i := 0; i < du; {
+						id := mask[i+j*du]
+						if id == 0 {
+							i++
+							continue
+						}
+
+						w := 1
+						for i+w < du && mask[i+w+j*du] == id {
+							w++
+						}
+
+						h := 1
+					grow:
+						for j+h < dv {
+							for k := 0; k < w; k++ {
+								if mask[i+k+(j+h)*du] != id {
+									break grow
+								}
+							}
+							h++
+						}
+
+						for l := 0; l < h; l++ {
+							for k := 0; k < w; k++ {
+								mask[i+k+(j+l)*du] = 0
+							}
+						}
+
+						var base [3]int
+						base[d] = origin[d] + s + side
+						base[u] = origin[u] + i
+						base[v] = origin[v] + j
+						m.addQuad(base, u, v, w, h, 2*d+side, id)
+						i += w
+					}
+				}
+			}
+		}
+	}
+
+	return m
+}
+
+// lookup returns the Block at chunk-relative coordinates x, which may lie
+// up to one voxel outside the chunk c along a single axis.
+func lookup(c *chunk, n *[6]*chunk, x [3]int) Block {
+	for d := 0; d < 3; d++ {
+		if x[d] < 0 {
+			x[d] += chunkDims[d]
+			c = n[2*d]
+		} else if x[d] >= chunkDims[d] {
+			x[d] -= chunkDims[d]
+			c = n[2*d+1]
+		} else {
+			continue
+		}
+		if c == nil {
+			return Block{}
+		}
+	}
+	return c[x[0]][x[1]][x[2]]
+}
+
+// addQuad appends a w by h quad spanning axes u and v, with its corner at
+// base and facing along faceNormals[face]. Vertices are wound
+// counter-clockwise when viewed from the front.
+func (m *Mesh) addQuad(base [3]int, u, v, w, h, face int, id uint) {
+	corners := [4][3]int{base, base, base, base}
+	corners[1][u] += w
+	corners[2][u] += w
+	corners[2][v] += h
+	corners[3][v] += h
+	if face%2 == 0 {
+		corners[1], corners[3] = corners[3], corners[1]
+	}
+
+	start := uint32(len(m.Ids))
+	normal := faceNormals[face]
+	for _, p := range corners {
+		m.Vertices = append(m.Vertices, float32(p[0]), float32(p[1]), float32(p[2]))
+		m.Normals = append(m.Normals, float32(normal[0]), float32(normal[1]), float32(normal[2]))
+		m.Ids = append(m.Ids, uint32(id))
+	}
+	m.Indices = append(m.Indices,
+		start, start+1, start+2,
+		start, start+2, start+3,
+	)
+}
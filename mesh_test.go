@@ -0,0 +1,137 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMeshEmpty(t *testing.T) {
+	var c chunk
+	var n [6]*chunk
+	m := buildMesh(&c, &n, [3]int{}, DefaultBlocks)
+	if len(m.Vertices) != 0 || len(m.Indices) != 0 {
+		t.Error("buildMesh returned faces for empty chunk")
+	}
+}
+
+func TestMeshSingleBlock(t *testing.T) {
+	var c chunk
+	var n [6]*chunk
+	c[3][4][5] = Block{7, 0}
+	m := buildMesh(&c, &n, [3]int{}, DefaultBlocks)
+	checkMesh(t, "single block", m, 6)
+
+	for i, id := range m.Ids {
+		if id != 7 {
+			t.Errorf("buildMesh returned Id %d for vertex %d, expected 7", id, i)
+		}
+	}
+	for i := 0; i < len(m.Vertices); i += 3 {
+		x, y, z := m.Vertices[i], m.Vertices[i+1], m.Vertices[i+2]
+		if x < 3 || x > 4 || y < 4 || y > 5 || z < 5 || z > 6 {
+			t.Errorf("buildMesh returned vertex (%v, %v, %v) outside block", x, y, z)
+		}
+	}
+}
+
+func TestMeshMerge(t *testing.T) {
+	var c chunk
+	var n [6]*chunk
+	for x := 0; x < ncx; x++ {
+		for z := 0; z < 3; z++ {
+			c[x][0][z] = Block{1, 0}
+		}
+	}
+	checkMesh(t, "merged slab", buildMesh(&c, &n, [3]int{}, DefaultBlocks), 6)
+
+	c[0][0][0] = Block{2, 0}
+	// The odd block in the corner splits the top, bottom, left and front
+	// faces of the slab, and contributes one face to each of them itself.
+	checkMesh(t, "slab with odd block", buildMesh(&c, &n, [3]int{}, DefaultBlocks), 2*3+2*2+2)
+}
+
+func TestMeshFullChunk(t *testing.T) {
+	var c, full chunk
+	for x := 0; x < ncx; x++ {
+		for y := 0; y < ncy; y++ {
+			for z := 0; z < ncz; z++ {
+				c[x][y][z] = Block{1, 0}
+				full[x][y][z] = Block{2, 0}
+			}
+		}
+	}
+
+	var n [6]*chunk
+	checkMesh(t, "full chunk", buildMesh(&c, &n, [3]int{}, DefaultBlocks), 6)
+
+	for i := range n {
+		n[i] = &full
+		checkMesh(t, "full chunk with neighbours", buildMesh(&c, &n, [3]int{}, DefaultBlocks), 5-i)
+	}
+}
+
+func TestMeshAdjacentIds(t *testing.T) {
+	var c chunk
+	var n [6]*chunk
+	c[0][0][0] = Block{1, 0}
+	c[1][0][0] = Block{2, 0}
+	checkMesh(t, "two different blocks", buildMesh(&c, &n, [3]int{}, DefaultBlocks), 10)
+}
+
+func TestFrameMesh(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(-1, 0, 0, Block{1, 0})
+	f.SetBlock(0, 0, 0, Block{1, 0})
+
+	// The shared face between the chunks must be culled on both sides
+	checkMesh(t, "frame chunk (-1, 0, 0)", f.Mesh(pos{-1, 0, 0}), 5)
+	checkMesh(t, "frame chunk (0, 0, 0)", f.Mesh(pos{0, 0, 0}), 5)
+
+	m := f.Mesh(pos{-1, 0, 0})
+	for i := 0; i < len(m.Vertices); i += 3 {
+		if m.Vertices[i] < -1 || m.Vertices[i] > 0 {
+			t.Errorf("Frame.Mesh returned vertex x %v outside block at -1", m.Vertices[i])
+		}
+	}
+}
+
+// checkMesh checks that m consists of the expected number of quads, that
+// the attribute arrays are consistent and that each triangle is wound
+// counter-clockwise about its normal.
+func checkMesh(t *testing.T, desc string, m *Mesh, quads int) {
+	if len(m.Indices) != 6*quads {
+		t.Errorf("buildMesh returned %d quads for %s, expected %d", len(m.Indices)/6, desc, quads)
+	}
+	if len(m.Vertices) != 3*4*quads || len(m.Normals) != len(m.Vertices) || len(m.Ids)*3 != len(m.Vertices) {
+		t.Error("buildMesh returned inconsistent attributes for " + desc)
+		return
+	}
+
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := m.Indices[i], m.Indices[i+1], m.Indices[i+2]
+		ax, ay, az := m.Vertices[3*a], m.Vertices[3*a+1], m.Vertices[3*a+2]
+		ux, uy, uz := m.Vertices[3*b]-ax, m.Vertices[3*b+1]-ay, m.Vertices[3*b+2]-az
+		vx, vy, vz := m.Vertices[3*c]-ax, m.Vertices[3*c+1]-ay, m.Vertices[3*c+2]-az
+		nx, ny, nz := uy*vz-uz*vy, uz*vx-ux*vz, ux*vy-uy*vx
+		dot := nx*m.Normals[3*a] + ny*m.Normals[3*a+1] + nz*m.Normals[3*a+2]
+		if dot <= 0 {
+			t.Error("buildMesh returned back-facing triangle for " + desc)
+			return
+		}
+	}
+}
+
+func TestMeshTransparent(t *testing.T) {
+	reg := NewBlockRegistry()
+	reg.Register(BlockDef{Id: 1, Name: "rock", Solid: true})
+	reg.Register(BlockDef{Id: 2, Name: "glass", Solid: true, Transparent: true})
+
+	var c chunk
+	var n [6]*chunk
+	c[0][0][0] = Block{1, 0}
+	c[1][0][0] = Block{2, 0}
+	c[2][0][0] = Block{2, 0}
+
+	// The rock face behind the glass stays visible, but faces between
+	// glass blocks and the glass face against the rock are culled
+	checkMesh(t, "rock behind glass", buildMesh(&c, &n, [3]int{}, reg), 6+5)
+}
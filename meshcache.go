@@ -0,0 +1,84 @@
+package main
+
+// MeshUploader stores meshes where a renderer can draw them, such as in
+// GPU buffers.
+type MeshUploader interface {
+	Upload(mesh *Mesh) error
+	Free(mesh *Mesh)
+}
+
+// meshEntry is a mesh built for a chunk, and the version of the chunk it
+// was built from.
+type meshEntry struct {
+	mesh    *Mesh
+	version uint
+}
+
+// MeshCache keeps an uploaded mesh for every chunk of a Frame, rebuilding
+// only the meshes of chunks which have changed.
+type MeshCache struct {
+	Frame    *Frame
+	Uploader MeshUploader
+	entries  map[pos]meshEntry
+}
+
+// NewMeshCache creates an empty cache of the meshes of f, uploaded with u.
+func NewMeshCache(f *Frame, u MeshUploader) *MeshCache {
+	return &MeshCache{f, u, make(map[pos]meshEntry)}
+}
+
+// Update rebuilds and uploads the meshes of new and changed chunks, and
+// frees the meshes of chunks which no longer exist. It returns the number
+// of meshes built. If an upload fails, the chunk keeps its old mesh and is
+// tried again by the next Update.
+func (c *MeshCache) Update() (built int, err error) {
+	for p, e := range c.entries {
+		if _, ok := c.Frame.chunks[p]; !ok {
+			c.Uploader.Free(e.mesh)
+			delete(c.entries, p)
+		}
+	}
+
+	for _, p := range c.Frame.chunkPositions() {
+		v := c.Frame.chunkVersion(p)
+		old, ok := c.entries[p]
+		if ok && old.version == v {
+			continue
+		}
+		mesh := c.Frame.Mesh(p)
+		if err = c.Uploader.Upload(mesh); err != nil {
+			return
+		}
+		if ok {
+			c.Uploader.Free(old.mesh)
+		}
+		c.entries[p] = meshEntry{mesh, v}
+		built++
+	}
+	return
+}
+
+// Draw draws the cached mesh of every chunk with r.
+func (c *MeshCache) Draw(r Renderer, modelview *Matrix4) {
+	for _, p := range c.positions() {
+		r.Draw(c.entries[p].mesh, modelview)
+	}
+}
+
+// Release frees every cached mesh.
+func (c *MeshCache) Release() {
+	for p, e := range c.entries {
+		c.Uploader.Free(e.mesh)
+		delete(c.entries, p)
+	}
+}
+
+// positions returns the positions of the cached chunks in a fixed order.
+func (c *MeshCache) positions() []pos {
+	ps := make([]pos, 0, len(c.entries))
+	for p := range c.entries {
+		ps = append(ps, p)
+	}
+	sortPositions(ps)
+	return ps
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeUploader records the meshes uploaded to it.
+type fakeUploader struct {
+	uploaded map[*Mesh]bool
+	uploads  int
+	frees    int
+	fail     bool
+}
+
+func newFakeUploader() *fakeUploader {
+	return &fakeUploader{uploaded: make(map[*Mesh]bool)}
+}
+
+func (u *fakeUploader) Upload(mesh *Mesh) error {
+	if u.fail {
+		return errors.New("upload failed")
+	}
+	u.uploaded[mesh] = true
+	u.uploads++
+	return nil
+}
+
+func (u *fakeUploader) Free(mesh *Mesh) {
+	if !u.uploaded[mesh] {
+		panic("freed mesh which was not uploaded")
+	}
+	delete(u.uploaded, mesh)
+	u.frees++
+}
+
+// recordRenderer is a Renderer which records the meshes drawn.
+type recordRenderer struct {
+	drawn []*Mesh
+}
+
+func (r *recordRenderer) Begin(projection *Matrix4)           {}
+func (r *recordRenderer) Draw(mesh *Mesh, modelview *Matrix4) { r.drawn = append(r.drawn, mesh) }
+func (r *recordRenderer) End() error                          { return nil }
+
+func TestChunkVersion(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(5, 5, 5, Block{1, 0})
+	f.SetBlock(16, 5, 5, Block{1, 0})
+	a, b := pos{0, 0, 0}, pos{1, 0, 0}
+	va, vb := f.chunkVersion(a), f.chunkVersion(b)
+
+	// Inside a chunk only that chunk changes
+	f.SetBlock(6, 5, 5, Block{1, 0})
+	if f.chunkVersion(a) == va || f.chunkVersion(b) != vb {
+		t.Error("SetBlock inside chunk did not change only its version")
+	}
+
+	// On an edge the neighbouring chunk changes too
+	va = f.chunkVersion(a)
+	f.SetBlock(15, 0, 0, Block{1, 0})
+	if f.chunkVersion(a) == va || f.chunkVersion(b) == vb {
+		t.Error("SetBlock on chunk edge did not change neighbour's version")
+	}
+
+	// Setting the same block changes nothing
+	va, vb = f.chunkVersion(a), f.chunkVersion(b)
+	f.SetBlock(15, 0, 0, Block{1, 0})
+	if f.chunkVersion(a) != va || f.chunkVersion(b) != vb {
+		t.Error("SetBlock of same block changed versions")
+	}
+
+	// Versions are never reused, even when a chunk is deleted
+	f.SetBlock(16, 5, 5, Block{})
+	if _, ok := f.chunks[b]; ok {
+		t.Fatal("SetBlock did not delete empty chunk")
+	}
+	f.SetBlock(16, 5, 5, Block{1, 0})
+	if f.chunkVersion(b) == vb {
+		t.Error("SetBlock reused version of deleted chunk")
+	}
+}
+
+func TestMeshCache(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	f.SetBlock(20, 0, 0, Block{1, 0})
+	f.SetBlock(40, 0, 0, Block{1, 0})
+	u := newFakeUploader()
+	c := NewMeshCache(f, u)
+
+	if built, err := c.Update(); err != nil || built != 3 || len(u.uploaded) != 3 {
+		t.Fatalf("Update built %d meshes, expected 3", built)
+	}
+	if built, _ := c.Update(); built != 0 {
+		t.Errorf("Update rebuilt %d unchanged meshes", built)
+	}
+
+	// Only the changed chunk is rebuilt, and its old mesh freed
+	old := c.entries[pos{1, 0, 0}].mesh
+	f.SetBlock(21, 0, 0, Block{1, 0})
+	if built, _ := c.Update(); built != 1 || u.uploaded[old] || len(u.uploaded) != 3 {
+		t.Errorf("Update built %d meshes after one changed, expected 1", built)
+	}
+	checkMesh(t, "cached mesh", c.entries[pos{1, 0, 0}].mesh, 6)
+
+	// An edge change rebuilds the neighbour, whose face is now hidden
+	f.SetBlock(16, 0, 0, Block{1, 0})
+	f.SetBlock(15, 0, 0, Block{1, 0})
+	if built, _ := c.Update(); built != 2 {
+		t.Errorf("Update built %d meshes after edge change, expected 2", built)
+	}
+
+	// Emptied chunks are freed
+	f.SetBlock(40, 0, 0, Block{})
+	if built, _ := c.Update(); built != 0 || len(u.uploaded) != 2 || len(c.entries) != 2 {
+		t.Errorf("Update did not free deleted chunk: %d uploaded", len(u.uploaded))
+	}
+
+	r := &recordRenderer{}
+	var m Matrix4
+	c.Draw(r, &m)
+	if len(r.drawn) != 2 || r.drawn[0] != c.entries[pos{0, 0, 0}].mesh || r.drawn[1] != c.entries[pos{1, 0, 0}].mesh {
+		t.Error("Draw did not draw cached meshes in order")
+	}
+
+	c.Release()
+	if len(u.uploaded) != 0 || len(c.entries) != 0 {
+		t.Error("Release did not free meshes")
+	}
+}
+
+func TestMeshCacheUploadError(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	u := newFakeUploader()
+	c := NewMeshCache(f, u)
+	c.Update()
+	mesh := c.entries[pos{}].mesh
+
+	f.SetBlock(1, 0, 0, Block{1, 0})
+	u.fail = true
+	if _, err := c.Update(); err == nil {
+		t.Error("Update did not return upload error")
+	}
+	if c.entries[pos{}].mesh != mesh || !u.uploaded[mesh] {
+		t.Error("Update did not keep old mesh after failed upload")
+	}
+
+	u.fail = false
+	if built, err := c.Update(); err != nil || built != 1 {
+		t.Error("Update did not retry failed upload")
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+)
+
+// OreRule describes how veins of one ore are scattered through a world.
+type OreRule struct {
+	Ore              Block   // block placed in veins
+	Host             Block   // only blocks of this kind are replaced by ore
+	MinY, MaxY       int     // veins lie between these heights, inclusive
+	Veins            float64 // average number of veins started in each chunk
+	MinSize, MaxSize int     // number of steps in each vein
+}
+
+// oreRuleFile is the JSON form of an OreRule, naming blocks as in a
+// BlockRegistry.
+type oreRuleFile struct {
+	Ore     string  `json:"ore"`
+	Host    string  `json:"host"`
+	MinY    *int    `json:"min_y"`
+	MaxY    *int    `json:"max_y"`
+	Veins   float64 `json:"veins"`
+	MinSize int     `json:"min_size"`
+	MaxSize int     `json:"max_size"`
+}
+
+// LoadOreRules reads a JSON array of ore rules from rd, looking up block
+// names in reg. Heights default to unlimited, and the host to rock.
+func LoadOreRules(rd io.Reader, reg *BlockRegistry) ([]OreRule, error) {
+	var file []oreRuleFile
+	if err := json.NewDecoder(rd).Decode(&file); err != nil {
+		return nil, fmt.Errorf("reading ore rules: %w", err)
+	}
+
+	rules := make([]OreRule, len(file))
+	for i, r := range file {
+		if r.Host == "" {
+			r.Host = "rock"
+		}
+		ore, ok := reg.ByName(r.Ore)
+		if !ok || ore.Id == 0 {
+			return nil, fmt.Errorf("ore rule %d: unknown ore block %q", i, r.Ore)
+		}
+		host, ok := reg.ByName(r.Host)
+		if !ok || host.Id == 0 {
+			return nil, fmt.Errorf("ore rule %d: unknown host block %q", i, r.Host)
+		}
+		rule := OreRule{
+			Ore:     Block{Id: ore.Id},
+			Host:    Block{Id: host.Id},
+			MinY:    math.MinInt,
+			MaxY:    math.MaxInt,
+			Veins:   r.Veins,
+			MinSize: r.MinSize,
+			MaxSize: r.MaxSize,
+		}
+		if r.MinY != nil {
+			rule.MinY = *r.MinY
+		}
+		if r.MaxY != nil {
+			rule.MaxY = *r.MaxY
+		}
+		if rule.MinY > rule.MaxY {
+			return nil, fmt.Errorf("ore rule %d: min_y above max_y", i)
+		}
+		if rule.Veins < 0 || rule.MinSize < 1 || rule.MaxSize < rule.MinSize {
+			return nil, fmt.Errorf("ore rule %d: bad vein count or size", i)
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+// LoadOreRulesFile reads ore rules from the named JSON file.
+func LoadOreRulesFile(name string, reg *BlockRegistry) (rules []OreRule, err error) {
+	err = loadFile(name, func(rd io.Reader) (err error) {
+		rules, err = LoadOreRules(rd, reg)
+		return
+	})
+	return
+}
+
+// OrePass seeds veins of ore into the chunks of a frame. The veins of a
+// chunk depend only on the seed, the rules and the chunk's position, and
+// stay inside the chunk, so chunks can be processed in any order.
+type OrePass struct {
+	Rules []OreRule
+	Seed  int64
+}
+
+// Place seeds veins into the chunk at chunk coordinates (cx, cy, cz),
+// replacing host blocks through SetBlock, and returns the number of blocks
+// replaced.
+func (o *OrePass) Place(f *Frame, cx, cy, cz int) (placed int) {
+	for n, r := range o.Rules {
+		rng := rand.New(rand.NewSource(chunkSeed(o.Seed, cx, cy, cz, n)))
+		veins := int(r.Veins)
+		if rng.Float64() < r.Veins-float64(veins) {
+			veins++
+		}
+		for v := 0; v < veins; v++ {
+			x := [3]int{rng.Intn(ncx), rng.Intn(ncy), rng.Intn(ncz)}
+			size := r.MinSize + rng.Intn(r.MaxSize-r.MinSize+1)
+			if y := cy*ncy + x[1]; y < r.MinY || y > r.MaxY {
+				continue
+			}
+			for s := 0; s < size; s++ {
+				bx, by, bz := cx*ncx+x[0], cy*ncy+x[1], cz*ncz+x[2]
+				if f.Block(bx, by, bz) == r.Host {
+					f.SetBlock(bx, by, bz, r.Ore)
+					placed++
+				}
+				// Wander to a neighbouring voxel, turning back at the
+				// edge of the chunk or the rule's heights
+				d, step := rng.Intn(3), 2*rng.Intn(2)-1
+				if !r.inside(cy, x, d, step) {
+					step = -step
+				}
+				if r.inside(cy, x, d, step) {
+					x[d] += step
+				}
+			}
+		}
+	}
+	return
+}
+
+// inside returns true if stepping by step along axis d from x, in the
+// chunk at height cy, stays within the chunk and the rule's heights.
+func (r OreRule) inside(cy int, x [3]int, d, step int) bool {
+	v := x[d] + step
+	if v < 0 || v >= chunkDims[d] {
+		return false
+	}
+	if d == 1 {
+		y := cy*ncy + v
+		return y >= r.MinY && y <= r.MaxY
+	}
+	return true
+}
+
+// PlaceAll seeds veins into every chunk of f, and returns the number of
+// blocks replaced.
+func (o *OrePass) PlaceAll(f *Frame) (placed int) {
+	for _, p := range f.chunkPositions() {
+		placed += o.Place(f, p.x, p.y, p.z)
+	}
+	return
+}
+
+// chunkSeed mixes a seed with a chunk position and rule number into the
+// seed of the chunk's random numbers for that rule.
+func chunkSeed(seed int64, cx, cy, cz, rule int) int64 {
+	h := uint64(seed)
+	for _, v := range [...]int{cx, cy, cz, rule} {
+		h ^= uint64(v) + 0x9e3779b97f4a7c15 + h<<6 + h>>2
+		h *= 0xbf58476d1ce4e5b9
+		h ^= h >> 31
+	}
+	return int64(h)
+}
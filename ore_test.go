@@ -0,0 +1,193 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/3SillyHats/DarkLogic/generate"
+)
+
+// Blocks placed by the generators.
+var (
+	rockBlock = Block{Id: generate.Rock}
+	iceBlock  = Block{Id: generate.Ice}
+)
+
+// solidFrame returns a frame with the chunks from (0, 0, 0) to (n-1, n-1,
+// n-1) filled with b.
+func solidFrame(n int, b Block) *Frame {
+	f := NewFrame()
+	for x := 0; x < n*ncx; x++ {
+		for y := 0; y < n*ncy; y++ {
+			for z := 0; z < n*ncz; z++ {
+				f.SetBlock(x, y, z, b)
+			}
+		}
+	}
+	return f
+}
+
+var testOres = []OreRule{
+	{Ore: Block{3, 0}, Host: rockBlock, MinY: math.MinInt, MaxY: math.MaxInt, Veins: 2.5, MinSize: 4, MaxSize: 12},
+	{Ore: Block{4, 0}, Host: rockBlock, MinY: math.MinInt, MaxY: math.MaxInt, Veins: 1, MinSize: 2, MaxSize: 6},
+}
+
+func TestOrePass(t *testing.T) {
+	o := &OrePass{testOres, 7}
+	f := solidFrame(2, rockBlock)
+	placed := o.PlaceAll(f)
+	if placed == 0 {
+		t.Fatal("PlaceAll placed no ore")
+	}
+	if f.Count(3)+f.Count(4) != placed || f.Count(1) != 8*ncx*ncy*ncz-placed {
+		t.Errorf("PlaceAll returned %d, but census counts %d ore", placed, f.Count(3)+f.Count(4))
+	}
+	checkCensus(t, "ore pass", f)
+
+	// Chunks processed in another order give the same blocks
+	g := solidFrame(2, rockBlock)
+	ps := g.chunkPositions()
+	for i := len(ps) - 1; i >= 0; i-- {
+		o.Place(g, ps[i].x, ps[i].y, ps[i].z)
+	}
+	for _, p := range ps {
+		if f.chunks[p] != g.chunks[p] {
+			t.Errorf("Place gave different ore in chunk %v when run in reverse order", p)
+		}
+	}
+
+	// A different seed gives different ore
+	h := solidFrame(2, rockBlock)
+	(&OrePass{testOres, 8}).PlaceAll(h)
+	if h.chunks[pos{}] == f.chunks[pos{}] {
+		t.Error("PlaceAll gave same ore for different seeds")
+	}
+}
+
+func TestOrePassHost(t *testing.T) {
+	o := &OrePass{testOres, 7}
+	f := solidFrame(1, iceBlock)
+	if placed := o.PlaceAll(f); placed != 0 || f.Count(2) != ncx*ncy*ncz {
+		t.Error("Place replaced blocks other than the host")
+	}
+	if placed := o.Place(NewFrame(), 0, 0, 0); placed != 0 {
+		t.Error("Place placed ore in empty space")
+	}
+}
+
+func TestOrePassDepth(t *testing.T) {
+	rule := testOres[0]
+	rule.Veins = 20
+	rule.MaxY = -1
+	o := &OrePass{[]OreRule{rule}, 7}
+	f := NewFrame()
+	for x := 0; x < ncx; x++ {
+		for y := -ncy; y < ncy; y++ {
+			for z := 0; z < ncz; z++ {
+				f.SetBlock(x, y, z, rockBlock)
+			}
+		}
+	}
+	o.PlaceAll(f)
+	if f.ChunkCensus(0, -1, 0)[3] == 0 {
+		t.Error("Place placed no ore below max_y")
+	}
+	if f.ChunkCensus(0, 0, 0)[3] != 0 {
+		t.Error("Place placed ore above max_y")
+	}
+
+	// Long veins in a narrow band never wander out of it
+	rule.MinY, rule.MaxY, rule.MinSize, rule.MaxSize = 3, 5, 40, 60
+	o = &OrePass{[]OreRule{rule}, 7}
+	f = solidFrame(1, rockBlock)
+	if o.PlaceAll(f) == 0 {
+		t.Fatal("Place placed no ore in band")
+	}
+	for x := 0; x < ncx; x++ {
+		for y := 0; y < ncy; y++ {
+			for z := 0; z < ncz; z++ {
+				if f.Block(x, y, z) == rule.Ore && (y < 3 || y > 5) {
+					t.Fatalf("Place placed ore at height %d, outside 3 to 5", y)
+				}
+			}
+		}
+	}
+
+	// A band one block high keeps veins flat
+	rule.MinY, rule.MaxY = 4, 4
+	o = &OrePass{[]OreRule{rule}, 7}
+	f = solidFrame(1, rockBlock)
+	o.PlaceAll(f)
+	for x := 0; x < ncx; x++ {
+		for z := 0; z < ncz; z++ {
+			if f.Block(x, 4, z) == rule.Ore {
+				f.SetBlock(x, 4, z, rockBlock)
+			}
+		}
+	}
+	if f.Count(3) != 0 {
+		t.Error("Place placed ore outside band one block high")
+	}
+}
+
+func TestLoadOreRules(t *testing.T) {
+	reg := NewBlockRegistry()
+	if err := reg.LoadFile("blocks.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadOreRules(strings.NewReader(`[
+		{"ore": "iron ore", "max_y": 0, "veins": 1.5, "min_size": 6, "max_size": 16},
+		{"ore": "copper ore", "host": "ice", "veins": 1, "min_size": 1, "max_size": 1}
+	]`), reg)
+	if err != nil {
+		t.Fatal("LoadOreRules returned error: " + err.Error())
+	}
+	expected := []OreRule{
+		{Block{3, 0}, rockBlock, math.MinInt, 0, 1.5, 6, 16},
+		{Block{4, 0}, iceBlock, math.MinInt, math.MaxInt, 1, 1, 1},
+	}
+	if len(rules) != len(expected) {
+		t.Fatalf("LoadOreRules returned %d rules, expected %d", len(rules), len(expected))
+	}
+	for i := range rules {
+		if rules[i] != expected[i] {
+			t.Errorf("LoadOreRules returned rule %v, expected %v", rules[i], expected[i])
+		}
+	}
+
+	for _, bad := range []string{
+		`{}`,
+		`[{"ore": "gold ore", "veins": 1, "min_size": 1, "max_size": 1}]`,
+		`[{"ore": "iron ore", "host": "cheese", "veins": 1, "min_size": 1, "max_size": 1}]`,
+		`[{"ore": "iron ore", "min_y": 5, "max_y": 4, "veins": 1, "min_size": 1, "max_size": 1}]`,
+		`[{"ore": "iron ore", "veins": -1, "min_size": 1, "max_size": 1}]`,
+		`[{"ore": "iron ore", "veins": 1, "min_size": 0, "max_size": 1}]`,
+		`[{"ore": "iron ore", "veins": 1, "min_size": 3, "max_size": 2}]`,
+	} {
+		if _, err := LoadOreRules(strings.NewReader(bad), reg); err == nil {
+			t.Error("LoadOreRules accepted " + bad)
+		}
+	}
+}
+
+func TestLoadOreRulesFile(t *testing.T) {
+	reg := NewBlockRegistry()
+	if err := reg.LoadFile("blocks.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOreRulesFile("ores.json", reg); err != nil {
+		t.Fatal("LoadOreRulesFile returned error for ores.json: " + err.Error())
+	}
+}
+
+func TestWorldOres(t *testing.T) {
+	w := NewWorld(NewFrame(), generate.NewTerrain(7))
+	w.Ores = &OrePass{testOres, 7}
+	w.GenerateAround(0, 0, 0, 1)
+	if w.Frame.Count(3) == 0 {
+		t.Error("World placed no ore")
+	}
+	checkCensus(t, "generated world", w.Frame)
+}
@@ -0,0 +1,100 @@
+package main
+
+// Body represents a Frame moving as a rigid body, with mass properties
+// derived from its voxels. Vectors are in the coordinate system of the
+// frame's parent, which is world space for root frames.
+type Body struct {
+	Frame           *Frame
+	Velocity        Vec3 // linear velocity of the centre of mass
+	AngularVelocity Vec3 // axis times angular speed in radians per second
+	Force           Vec3 // force applied at the centre of mass every step
+	Torque          Vec3 // torque applied every step
+	Static          bool // the body is not moved by the simulation
+}
+
+// Physics steps a set of rigid bodies with a fixed timestep, independent
+// of rendering, so that the simulation is deterministic.
+type Physics struct {
+	Timestep float64 // length of a single step in seconds
+	Gravity  Vec3    // acceleration applied to every body
+	Bodies   []*Body
+
+	accumulator float64
+}
+
+// NewPhysics creates a simulation with no bodies or gravity which steps
+// by timestep seconds.
+func NewPhysics(timestep float64) *Physics {
+	return &Physics{Timestep: timestep}
+}
+
+// AddBody adds f to the simulation as a body at rest.
+func (p *Physics) AddBody(f *Frame) *Body {
+	b := &Body{Frame: f}
+	p.Bodies = append(p.Bodies, b)
+	return b
+}
+
+// Advance runs as many fixed steps as fit into dt seconds plus the time
+// left over from previous calls, and returns the number of steps run.
+func (p *Physics) Advance(dt float64) (steps int) {
+	p.accumulator += dt
+	for p.accumulator >= p.Timestep {
+		p.Step()
+		p.accumulator -= p.Timestep
+		steps++
+	}
+	return
+}
+
+// Step advances every body by a single timestep.
+func (p *Physics) Step() {
+	p.stepBy(p.Timestep)
+}
+
+// stepBy advances every body by h seconds.
+func (p *Physics) stepBy(h float64) {
+	for _, b := range p.Bodies {
+		b.step(h, p.Gravity)
+	}
+}
+
+// step integrates the velocities of the body over h seconds with the
+// semi-implicit Euler method, and moves its Frame accordingly, rotating
+// about its centre of mass.
+func (b *Body) step(h float64, gravity Vec3) {
+	m := b.Frame.Mass()
+	if b.Static || m < minMass {
+		return
+	}
+	s := b.Frame.Transform
+
+	b.Velocity = b.Velocity.Add(gravity.Add(b.Force.Mul(1 / m)).Mul(h))
+
+	// Apply the local inertia tensor to the torque in local axes. The
+	// scaling of the transform into and out of local coordinates cancels,
+	// leaving the inertia's own scaling by the square of the scale.
+	if b.Torque != (Vec3{}) {
+		if inv, ok := inverse3(b.Frame.Inertia()); ok {
+			tl := s.Inverse().TransformRel(b.Torque)
+			al := Vec3{
+				inv[0][0]*tl.X + inv[0][1]*tl.Y + inv[0][2]*tl.Z,
+				inv[1][0]*tl.X + inv[1][1]*tl.Y + inv[1][2]*tl.Z,
+				inv[2][0]*tl.X + inv[2][1]*tl.Y + inv[2][2]*tl.Z,
+			}
+			a := s.TransformRel(al).Mul(1 / (s.scale * s.scale))
+			b.AngularVelocity = b.AngularVelocity.Add(a.Mul(h))
+		}
+	}
+
+	com := b.Frame.CentreOfMass()
+	before := s.TransformAbs(com)
+
+	if l := b.AngularVelocity.Length(); l > 0 {
+		s.Rotate(QuatAxisAngle(l*h, b.AngularVelocity.Mul(1/l)))
+	}
+
+	// Put the centre of mass back where it was before rotating, then move it
+	after := s.TransformAbs(com)
+	s.Translate(before.Sub(after).Add(b.Velocity.Mul(h)))
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMass(t *testing.T) {
+	f := NewFrame()
+	if f.Mass() != 0 {
+		t.Error("NewFrame returned frame with mass")
+	}
+
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	if f.Mass() != 1 {
+		t.Errorf("Mass returned %v for a single block, expected 1", f.Mass())
+	}
+	checkVector(t, "CentreOfMass for a single block", f.CentreOfMass(), Vec3{0.5, 0.5, 0.5})
+	checkInertia(t, "a single block", f.Inertia(), [3][3]float64{
+		{1.0 / 6, 0, 0},
+		{0, 1.0 / 6, 0},
+		{0, 0, 1.0 / 6},
+	})
+
+	f.SetBlock(1, 0, 0, Block{1, 0})
+	checkVector(t, "CentreOfMass for two blocks", f.CentreOfMass(), Vec3{1, 0.5, 0.5})
+	checkInertia(t, "two blocks", f.Inertia(), [3][3]float64{
+		{2.0 / 6, 0, 0},
+		{0, 2.0/6 + 0.5, 0},
+		{0, 0, 2.0/6 + 0.5},
+	})
+
+	f.SetBlock(1, 0, 0, Block{})
+	f.SetBlock(0, 0, 0, Block{})
+	if math.Abs(f.Mass()) > 1e-15 {
+		t.Error("SetBlock did not remove mass")
+	}
+}
+
+func TestMassCleared(t *testing.T) {
+	f := NewFrame()
+	f.Registry = NewBlockRegistry()
+	f.Registry.Register(BlockDef{Id: 1, Name: "a", Solid: true, Density: 0.1})
+	f.Registry.Register(BlockDef{Id: 2, Name: "b", Solid: true, Density: 0.7})
+	f.Registry.Register(BlockDef{Id: 3, Name: "c", Solid: true, Density: 2.3})
+	for i := 0; i < 30; i++ {
+		f.SetBlock(i-10, i%3, i%2, Block{uint(1 + i%3), 0})
+	}
+	for i := 0; i < 30; i++ {
+		f.SetBlock(i-10, i%3, i%2, Block{})
+	}
+
+	// Rounding in the running sums must not leave a tiny mass behind
+	if len(f.chunks) != 0 || f.Mass() != 0 || f.CentreOfMass() != (Vec3{}) {
+		t.Errorf("cleared frame has mass %v and centre %v", f.Mass(), f.CentreOfMass())
+	}
+	p := NewPhysics(0.1)
+	b := p.AddBody(f)
+	b.Force = Vec3{1, 0, 0}
+	p.Step()
+	checkVector(t, "Step for cleared frame", f.Transform.TransformAbs(Vec3{}), Vec3{})
+
+	// Nor may a near-zero mass left in a frame with blocks move it
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	f.mass.mass = 1e-15
+	p.Step()
+	checkVector(t, "Step for frame with tiny mass", f.Transform.TransformAbs(Vec3{}), Vec3{})
+}
+
+func TestMassDensity(t *testing.T) {
+	f := NewFrame()
+	f.Registry = NewBlockRegistry()
+	f.Registry.Register(BlockDef{Id: 1, Name: "heavy", Solid: true, Density: 3})
+
+	f.SetBlock(-1, 0, 0, Block{1, 0})
+	f.SetBlock(0, 0, 0, Block{2, 0})
+	if f.Mass() != 4 {
+		t.Errorf("Mass returned %v, expected 4", f.Mass())
+	}
+	checkVector(t, "CentreOfMass with densities", f.CentreOfMass(), Vec3{0.5 - 3.0/4, 0.5, 0.5})
+
+	// Replacing a block must swap its contribution
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	if f.Mass() != 6 {
+		t.Errorf("Mass returned %v after replacing block, expected 6", f.Mass())
+	}
+
+	f.Registry.Register(BlockDef{Id: 2, Name: "light", Solid: true, Density: 0.5})
+	f.SetBlock(5, 5, -20, Block{2, 0})
+	g := *f
+	g.RecomputeMass()
+	if math.Abs(f.Mass()-g.Mass()) > 1e-12 || diffInertia(f.Inertia(), g.Inertia()) > 1e-9 {
+		t.Error("SetBlock and RecomputeMass disagree")
+	}
+}
+
+func TestPhysicsLinear(t *testing.T) {
+	p := NewPhysics(0.25)
+	f := NewFrame()
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	b := p.AddBody(f)
+	b.Velocity = Vec3{1, 2, 0}
+
+	if steps := p.Advance(0.625); steps != 2 {
+		t.Errorf("Advance ran %d steps for 2.5 timesteps, expected 2", steps)
+	}
+	if steps := p.Advance(0.125); steps != 1 {
+		t.Errorf("Advance ran %d steps with accumulated time, expected 1", steps)
+	}
+	checkVector(t, "Step with velocity", f.Transform.TransformAbs(Vec3{0, 0, 0}), Vec3{0.75, 1.5, 0})
+
+	p.Gravity = Vec3{0, 0, -10}
+	b.Velocity = Vec3{}
+	p.Step()
+	p.Step()
+	// Semi-implicit Euler moves by g h^2 (1 + 2)
+	checkVector(t, "Step with gravity", f.Transform.TransformAbs(Vec3{0, 0, 0}), Vec3{0.75, 1.5, -1.875})
+
+	b.Static = true
+	p.Step()
+	checkVector(t, "Step for static body", f.Transform.TransformAbs(Vec3{0, 0, 0}), Vec3{0.75, 1.5, -1.875})
+}
+
+func TestPhysicsAngular(t *testing.T) {
+	p := NewPhysics(0.01)
+	f := NewFrame()
+	f.Transform.SetScale(2)
+	f.Transform.SetTranslation(Vec3{5, 0, 0})
+	for x := 0; x < 3; x++ {
+		f.SetBlock(x, 0, 0, Block{1, 0})
+	}
+	b := p.AddBody(f)
+	b.AngularVelocity = Vec3{0, 0, math.Pi}
+
+	// Half a turn about the z axis through the centre of mass, which is
+	// at world (8, 1, 1)
+	for i := 0; i < 100; i++ {
+		p.Step()
+	}
+	com := f.CentreOfMass()
+	checkVector(t, "centre of mass after spinning", f.Transform.TransformAbs(com), Vec3{8, 1, 1})
+	checkVector(t, "x axis after half a turn", f.Transform.TransformRel(Vec3{1, 0, 0}), Vec3{-2, 0, 0})
+
+	// A torque about z accelerates the spin by torque / (s^2 Izz)
+	b.AngularVelocity = Vec3{}
+	b.Torque = Vec3{0, 0, 4}
+	p.Step()
+	izz := f.Inertia()[2][2]
+	if math.Abs(b.AngularVelocity.Z-4/(4*izz)*0.01) > 1e-12 {
+		t.Errorf("Step returned angular velocity %v, expected %v", b.AngularVelocity.Z, 4/(4*izz)*0.01)
+	}
+}
+
+func TestPhysicsDeterministic(t *testing.T) {
+	run := func() SQT {
+		p := NewPhysics(1.0 / 60)
+		p.Gravity = Vec3{0, -9.8, 0}
+		f := NewFrame()
+		for i := -5; i < 5; i++ {
+			f.SetBlock(i, i*i, -i, Block{1, 0})
+		}
+		b := p.AddBody(f)
+		b.AngularVelocity = Vec3{0.3, -1, 2}
+		b.Torque = Vec3{1, 0, 0}
+		for i := 0; i < 600; i++ {
+			p.Step()
+		}
+		return *f.Transform
+	}
+
+	if run() != run() {
+		t.Error("Physics did not produce the same result twice")
+	}
+}
+
+func checkVector(t *testing.T, desc string, v, expected Vec3) {
+	if v.Sub(expected).Length() > 1e-9 {
+		t.Errorf("%s returned %v, expected %v", desc, v, expected)
+	}
+}
+
+func checkInertia(t *testing.T, desc string, i, expected [3][3]float64) {
+	if diffInertia(i, expected) > 1e-12 {
+		t.Errorf("Inertia returned %v for %s, expected %v", i, desc, expected)
+	}
+}
+
+func diffInertia(a, b [3][3]float64) (d float64) {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			d = math.Max(d, math.Abs(a[i][j]-b[i][j]))
+		}
+	}
+	return
+}
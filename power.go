@@ -0,0 +1,350 @@
+package main
+
+import (
+	"sort"
+)
+
+// PowerStats describes a power network. Power is energy per second.
+type PowerStats struct {
+	Blocks     int     // conductive blocks in the network
+	Generation float64 // power produced by generators
+	Demand     float64 // power wanted by all consumers
+	Supplied   float64 // power delivered to consumers in the last tick
+	Wasted     float64 // power generated in the last tick with nowhere to go
+	Stored     float64 // energy held by batteries
+	Capacity   float64 // energy the batteries can hold
+	BrownedOut int     // consumers left without power in the last tick
+}
+
+// PowerNetwork is a set of face-connected conductive blocks which share
+// power between their generators, batteries and consumers.
+type PowerNetwork struct {
+	id     int
+	grid   *PowerGrid
+	blocks map[[3]int]bool
+
+	// Results of the last tick
+	supplied, wasted float64
+	brownedOut       int
+}
+
+// Stats returns the current make-up of the network, and what happened to
+// its power in the last tick.
+func (n *PowerNetwork) Stats() PowerStats {
+	s := PowerStats{
+		Blocks:     len(n.blocks),
+		Supplied:   n.supplied,
+		Wasted:     n.wasted,
+		BrownedOut: n.brownedOut,
+	}
+	for _, v := range n.voxels() {
+		p := n.grid.part(v)
+		s.Generation += p.Generation
+		s.Demand += p.Demand
+		s.Capacity += p.Capacity
+		s.Stored += n.grid.charge[v]
+	}
+	return s
+}
+
+// voxels returns the blocks of the network in lessVoxel order, so that
+// sums over them round the same way every time.
+func (n *PowerNetwork) voxels() [][3]int {
+	vs := make([][3]int, 0, len(n.blocks))
+	for v := range n.blocks {
+		vs = append(vs, v)
+	}
+	sort.Slice(vs, func(i, j int) bool { return lessVoxel(vs[i], vs[j]) })
+	return vs
+}
+
+// PowerGrid divides the conductive blocks of a frame into power networks
+// and shares out power within each of them every tick. The networks are
+// kept up to date as blocks of the frame change, re-flooding only the
+// network a removed block belonged to.
+type PowerGrid struct {
+	Frame   *Frame
+	Parts   Parts
+	netOf   map[[3]int]*PowerNetwork
+	nets    map[*PowerNetwork]bool
+	charge  map[[3]int]float64 // energy held by each battery
+	powered map[[3]int]bool    // consumers powered in the last tick
+	lastId  int
+}
+
+// NewPowerGrid finds the power networks of f, with parts defined by parts,
+// and follows changes to its blocks. Batteries start empty.
+func NewPowerGrid(f *Frame, parts Parts) *PowerGrid {
+	g := &PowerGrid{
+		Frame:   f,
+		Parts:   parts,
+		netOf:   make(map[[3]int]*PowerNetwork),
+		nets:    make(map[*PowerNetwork]bool),
+		charge:  make(map[[3]int]float64),
+		powered: make(map[[3]int]bool),
+	}
+	for _, p := range f.chunkPositions() {
+		c := f.chunks[p]
+		for i := 0; i < ncx; i++ {
+			for j := 0; j < ncy; j++ {
+				for k := 0; k < ncz; k++ {
+					v := [3]int{p.x*ncx + i, p.y*ncy + j, p.z*ncz + k}
+					if _, ok := g.netOf[v]; !ok && parts[c[i][j][k].Id].Conducts() {
+						n := g.newNetwork()
+						for _, u := range g.flood(v, nil) {
+							n.blocks[u] = true
+							g.netOf[u] = n
+						}
+					}
+				}
+			}
+		}
+	}
+	f.Observe(g)
+	return g
+}
+
+// Detach stops the grid following changes to its frame.
+func (g *PowerGrid) Detach() {
+	g.Frame.Unobserve(g)
+}
+
+// Networks returns the power networks of the frame, oldest first.
+func (g *PowerGrid) Networks() []*PowerNetwork {
+	nets := make([]*PowerNetwork, 0, len(g.nets))
+	for n := range g.nets {
+		nets = append(nets, n)
+	}
+	sort.Slice(nets, func(i, j int) bool { return nets[i].id < nets[j].id })
+	return nets
+}
+
+// Network returns the power network containing the block at local voxel
+// coordinates (x, y, z), or nil if the block is not conductive.
+func (g *PowerGrid) Network(x, y, z int) *PowerNetwork {
+	return g.netOf[[3]int{x, y, z}]
+}
+
+// Powered returns true if the block at local voxel coordinates (x, y, z)
+// is a consumer which received the power it wanted in the last tick.
+func (g *PowerGrid) Powered(x, y, z int) bool {
+	return g.powered[[3]int{x, y, z}]
+}
+
+// Charge returns the energy held by the battery at local voxel coordinates
+// (x, y, z).
+func (g *PowerGrid) Charge(x, y, z int) float64 {
+	return g.charge[[3]int{x, y, z}]
+}
+
+// Tick shares out dt seconds of power in every network. Consumers are
+// served from generation and then from batteries, highest priority first;
+// once one cannot be served it and every consumer after it browns out, so
+// that low priority consumers never starve higher ones. Energy left over
+// charges the batteries, and whatever does not fit is wasted.
+func (g *PowerGrid) Tick(dt float64) {
+	for _, n := range g.Networks() {
+		g.tick(n, dt)
+	}
+}
+
+func (g *PowerGrid) tick(n *PowerNetwork, dt float64) {
+	var consumers, batteries [][3]int
+	available, capacity := 0.0, 0.0
+	for _, v := range n.voxels() {
+		p := g.part(v)
+		available += p.Generation * dt
+		if p.Demand > 0 {
+			consumers = append(consumers, v)
+		}
+		if p.Capacity > 0 {
+			batteries = append(batteries, v)
+			available += g.charge[v]
+			capacity += p.Capacity
+		}
+	}
+	sort.SliceStable(consumers, func(i, j int) bool {
+		return g.part(consumers[i]).Priority > g.part(consumers[j]).Priority
+	})
+
+	n.supplied, n.wasted, n.brownedOut = 0, 0, 0
+	for _, v := range consumers {
+		need := g.part(v).Demand * dt
+		if n.brownedOut == 0 && need <= available {
+			available -= need
+			n.supplied += need
+			g.powered[v] = true
+		} else {
+			n.brownedOut++
+			delete(g.powered, v)
+		}
+	}
+
+	// Spread what is left over the batteries in proportion to their
+	// capacity, so a battery removed later takes its fair share with it
+	if available > capacity {
+		n.wasted = available - capacity
+		available = capacity
+	}
+	for _, v := range batteries {
+		g.charge[v] = available * g.part(v).Capacity / capacity
+	}
+	if dt > 0 {
+		n.supplied /= dt
+		n.wasted /= dt
+	}
+}
+
+// BlockChanged updates the networks after a block of the frame changes.
+func (g *PowerGrid) BlockChanged(f *Frame, x, y, z int, old, b Block) {
+	v := [3]int{x, y, z}
+	was, is := g.Parts[old.Id].Conducts(), g.Parts[b.Id].Conducts()
+	delete(g.powered, v)
+	if c := g.Parts[b.Id].Capacity; g.charge[v] > c {
+		g.charge[v] = c
+	}
+	if g.charge[v] == 0 {
+		delete(g.charge, v)
+	}
+	switch {
+	case is && !was:
+		g.add(v)
+	case was && !is:
+		g.remove(v)
+	}
+}
+
+// add joins the conductive block at v to the networks of its neighbours,
+// merging them into the largest if there are several.
+func (g *PowerGrid) add(v [3]int) {
+	if _, ok := g.netOf[v]; ok {
+		return
+	}
+	var join []*PowerNetwork
+	for _, d := range faceNormals {
+		if n, ok := g.netOf[[3]int{v[0] + d[0], v[1] + d[1], v[2] + d[2]}]; ok {
+			join = append(join, n)
+		}
+	}
+	if len(join) == 0 {
+		n := g.newNetwork()
+		n.blocks[v] = true
+		g.netOf[v] = n
+		return
+	}
+
+	into := join[0]
+	for _, n := range join[1:] {
+		if len(n.blocks) > len(into.blocks) || len(n.blocks) == len(into.blocks) && n.id < into.id {
+			into = n
+		}
+	}
+	for _, n := range join {
+		if n == into || !g.nets[n] {
+			continue
+		}
+		for u := range n.blocks {
+			into.blocks[u] = true
+			g.netOf[u] = into
+		}
+		delete(g.nets, n)
+	}
+	into.blocks[v] = true
+	g.netOf[v] = into
+}
+
+// remove takes the block at v out of its network, splitting the network
+// if that disconnected it. The largest piece keeps the network and the
+// others become new ones.
+func (g *PowerGrid) remove(v [3]int) {
+	// The block may not be in a network if its part changed after the
+	// grid was built
+	n, ok := g.netOf[v]
+	if !ok {
+		return
+	}
+	delete(g.netOf, v)
+	delete(n.blocks, v)
+	if len(n.blocks) == 0 {
+		delete(g.nets, n)
+		return
+	}
+
+	var pieces [][][3]int
+	seen := make(map[[3]int]bool)
+	for _, d := range faceNormals {
+		u := [3]int{v[0] + d[0], v[1] + d[1], v[2] + d[2]}
+		if n.blocks[u] && !seen[u] {
+			piece := g.flood(u, n.blocks)
+			for _, w := range piece {
+				seen[w] = true
+			}
+			pieces = append(pieces, piece)
+		}
+	}
+	if len(pieces) < 2 {
+		return
+	}
+
+	largest := 0
+	for i, piece := range pieces {
+		if len(piece) > len(pieces[largest]) {
+			largest = i
+		}
+	}
+	for i, piece := range pieces {
+		if i == largest {
+			continue
+		}
+		m := g.newNetwork()
+		for _, w := range piece {
+			delete(n.blocks, w)
+			m.blocks[w] = true
+			g.netOf[w] = m
+		}
+	}
+}
+
+// flood returns the conductive blocks face-connected to start. If within
+// is not nil, only blocks in it are visited.
+func (g *PowerGrid) flood(start [3]int, within map[[3]int]bool) [][3]int {
+	visited := map[[3]int]bool{start: true}
+	blocks := [][3]int{start}
+	for i := 0; i < len(blocks); i++ {
+		v := blocks[i]
+		for _, d := range faceNormals {
+			u := [3]int{v[0] + d[0], v[1] + d[1], v[2] + d[2]}
+			if visited[u] {
+				continue
+			}
+			if within != nil && !within[u] || within == nil && !g.part(u).Conducts() {
+				continue
+			}
+			visited[u] = true
+			blocks = append(blocks, u)
+		}
+	}
+	return blocks
+}
+
+func (g *PowerGrid) newNetwork() *PowerNetwork {
+	g.lastId++
+	n := &PowerNetwork{id: g.lastId, grid: g, blocks: make(map[[3]int]bool)}
+	g.nets[n] = true
+	return n
+}
+
+// part returns the part definition of the block at v.
+func (g *PowerGrid) part(v [3]int) PartDef {
+	return g.Parts[g.Frame.Block(v[0], v[1], v[2]).Id]
+}
+
+// lessVoxel orders voxels by x, then y, then z.
+func lessVoxel(a, b [3]int) bool {
+	for d := range a {
+		if a[d] != b[d] {
+			return a[d] < b[d]
+		}
+	}
+	return false
+}
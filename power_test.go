@@ -0,0 +1,237 @@
+package main
+
+import (
+	"testing"
+)
+
+// Block Ids of powerParts
+var (
+	wireBlock     = Block{1, 0}
+	panelBlock    = Block{2, 0}
+	batteryBlock  = Block{3, 0}
+	lampBlock     = Block{4, 0}
+	motorBlock    = Block{5, 0}
+	hullBlock     = Block{6, 0}
+	bigPanelBlock = Block{7, 0}
+)
+
+var powerParts = Parts{
+	1: {Block: 1, Conductive: true},
+	2: {Block: 2, Generation: 4},
+	3: {Block: 3, Capacity: 10},
+	4: {Block: 4, Demand: 3, Priority: 2},
+	5: {Block: 5, Demand: 1, Priority: 1},
+	7: {Block: 7, Generation: 100},
+}
+
+// line sets blocks from (x0, 0, 0) to (x1, 0, 0) inclusive to b.
+func line(f *Frame, x0, x1 int, b Block) {
+	for x := x0; x <= x1; x++ {
+		f.SetBlock(x, 0, 0, b)
+	}
+}
+
+// checkNetworks checks that the grid's networks match a fresh flood of
+// its frame.
+func checkNetworks(t *testing.T, name string, g *PowerGrid) {
+	fresh := NewPowerGrid(g.Frame, g.Parts)
+	fresh.Detach()
+	if len(fresh.nets) != len(g.nets) {
+		t.Errorf("%s: grid has %d networks, expected %d", name, len(g.nets), len(fresh.nets))
+	}
+	if len(fresh.netOf) != len(g.netOf) {
+		t.Errorf("%s: grid has %d conductive blocks, expected %d", name, len(g.netOf), len(fresh.netOf))
+	}
+	for v, n := range fresh.netOf {
+		for u := range n.blocks {
+			if g.netOf[u] != g.netOf[v] {
+				t.Errorf("%s: %v and %v are not in the same network", name, u, v)
+			}
+		}
+	}
+	for n := range g.nets {
+		for v := range n.blocks {
+			if g.netOf[v] != n {
+				t.Errorf("%s: network of %v does not contain it", name, v)
+			}
+		}
+	}
+}
+
+func TestPowerGridNetworks(t *testing.T) {
+	f := NewFrame()
+	line(f, 0, 4, wireBlock)
+	line(f, 6, 8, wireBlock)
+	f.SetBlock(5, 0, 0, hullBlock)
+	f.SetBlock(0, 1, 0, panelBlock)
+	g := NewPowerGrid(f, powerParts)
+
+	if len(g.Networks()) != 2 || g.Network(0, 1, 0) != g.Network(4, 0, 0) || g.Network(5, 0, 0) != nil {
+		t.Fatal("NewPowerGrid did not find two networks")
+	}
+	if s := g.Network(0, 0, 0).Stats(); s.Blocks != 6 || s.Generation != 4 {
+		t.Errorf("network has stats %+v", s)
+	}
+
+	// Joining the networks merges them into the larger
+	big := g.Network(0, 0, 0)
+	f.SetBlock(5, 0, 0, wireBlock)
+	if len(g.Networks()) != 1 || g.Network(8, 0, 0) != big {
+		t.Error("SetBlock did not merge networks")
+	}
+	checkNetworks(t, "merged", g)
+
+	// Cutting it splits it again, the larger piece keeping the network
+	f.SetBlock(3, 0, 0, Block{})
+	if len(g.Networks()) != 2 || g.Network(8, 0, 0) != big || g.Network(0, 0, 0) == big {
+		t.Error("SetBlock did not split network")
+	}
+	checkNetworks(t, "split", g)
+
+	// Cutting a loop does not split it
+	f.SetBlock(3, 0, 0, wireBlock)
+	line(f, 0, 8, wireBlock)
+	for x := 0; x <= 8; x++ {
+		f.SetBlock(x, 2, 0, wireBlock)
+	}
+	f.SetBlock(8, 1, 0, wireBlock)
+	f.SetBlock(4, 0, 0, Block{})
+	if len(g.Networks()) != 1 {
+		t.Error("cutting a loop split network")
+	}
+	checkNetworks(t, "loop", g)
+
+	// Replacing one conductor with another keeps the network
+	n := g.Network(0, 0, 0)
+	f.SetBlock(1, 0, 0, batteryBlock)
+	if g.Network(1, 0, 0) != n || len(g.Networks()) != 1 {
+		t.Error("replacing conductor changed networks")
+	}
+
+	// Removing the last block of a network removes it
+	f.SetBlock(20, 0, 0, wireBlock)
+	if len(g.Networks()) != 2 {
+		t.Error("isolated conductor did not make a network")
+	}
+	f.SetBlock(20, 0, 0, Block{})
+	if len(g.Networks()) != 1 {
+		t.Error("removing isolated conductor did not remove its network")
+	}
+	checkNetworks(t, "final", g)
+}
+
+func TestPowerGridTick(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(0, 0, 0, panelBlock)
+	f.SetBlock(1, 0, 0, lampBlock)
+	f.SetBlock(2, 0, 0, motorBlock)
+	f.SetBlock(3, 0, 0, batteryBlock)
+	f.SetBlock(4, 0, 0, batteryBlock)
+	g := NewPowerGrid(f, powerParts)
+	n := g.Network(0, 0, 0)
+
+	// Generation exactly covers demand
+	g.Tick(1)
+	s := n.Stats()
+	if !g.Powered(1, 0, 0) || !g.Powered(2, 0, 0) || s.BrownedOut != 0 || s.Supplied != 4 {
+		t.Errorf("Tick did not power all consumers: %+v", s)
+	}
+	if s.Stored != 0 || s.Capacity != 20 || s.Demand != 4 || s.Generation != 4 || s.Wasted != 0 {
+		t.Errorf("Tick gave stats %+v", s)
+	}
+
+	// Spare power charges the batteries evenly, and the rest is wasted
+	f.SetBlock(0, 0, 0, bigPanelBlock)
+	g.Tick(1)
+	if s := n.Stats(); s.Stored != 20 || s.Wasted != 76 {
+		t.Errorf("Tick did not charge batteries: %+v", s)
+	}
+	if g.Charge(3, 0, 0) != 10 || g.Charge(4, 0, 0) != 10 {
+		t.Error("Tick did not share charge between batteries")
+	}
+
+	// Batteries carry consumers through a lack of generation
+	f.SetBlock(0, 0, 0, wireBlock)
+	g.Tick(4)
+	if s := n.Stats(); !g.Powered(1, 0, 0) || !g.Powered(2, 0, 0) || s.Stored != 4 || s.Supplied != 4 {
+		t.Errorf("Tick did not power consumers from batteries: %+v", s)
+	}
+
+	// When they run low the low priority consumer browns out first
+	g.Tick(1.25)
+	if s := n.Stats(); !g.Powered(1, 0, 0) || g.Powered(2, 0, 0) || s.BrownedOut != 1 || s.Stored != 0.25 || s.Supplied != 3 {
+		t.Errorf("Tick did not brown out low priority consumer: %+v", s)
+	}
+
+	// A lower priority consumer which would fit still browns out behind a
+	// higher one which does not
+	g.Tick(0.1)
+	if s := n.Stats(); g.Powered(1, 0, 0) || g.Powered(2, 0, 0) || s.BrownedOut != 2 || s.Stored != 0.25 {
+		t.Errorf("Tick powered consumers out of priority order: %+v", s)
+	}
+
+	// Removing a battery loses its share of the charge
+	f.SetBlock(4, 0, 0, Block{})
+	if s := n.Stats(); s.Stored != 0.125 || s.Capacity != 10 {
+		t.Errorf("removing battery left stats %+v", s)
+	}
+
+	// Separate networks do not share power
+	f.SetBlock(0, 0, 0, bigPanelBlock)
+	f.SetBlock(1, 0, 0, Block{})
+	g.Tick(1)
+	if g.Powered(2, 0, 0) || g.Network(0, 0, 0).Stats().Wasted != 100 {
+		t.Error("Tick shared power between networks")
+	}
+}
+
+func TestPowerGridChangedParts(t *testing.T) {
+	f := NewFrame()
+	line(f, 0, 2, wireBlock)
+	f.SetBlock(3, 0, 0, hullBlock)
+	parts := Parts{}
+	for id, p := range powerParts {
+		parts[id] = p
+	}
+	g := NewPowerGrid(f, parts)
+
+	// Hull becomes conductive after the grid was built, so editing it
+	// must not find it in a network
+	parts[hullBlock.Id] = PartDef{Block: hullBlock.Id, Conductive: true}
+	f.SetBlock(3, 0, 0, Block{})
+	if len(g.Networks()) != 1 || g.Network(3, 0, 0) != nil || g.Network(2, 0, 0).Stats().Blocks != 3 {
+		t.Error("removing block with changed part changed networks")
+	}
+	f.SetBlock(3, 0, 0, hullBlock)
+	if len(g.Networks()) != 1 || g.Network(3, 0, 0) != g.Network(0, 0, 0) {
+		t.Error("placing block with changed part did not join network")
+	}
+	checkNetworks(t, "changed parts", g)
+}
+
+func TestPowerGridDeterministic(t *testing.T) {
+	parts := Parts{
+		1: {Block: 1, Generation: 0.1},
+		2: {Block: 2, Capacity: 0.3},
+		3: {Block: 3, Demand: 0.7},
+	}
+	f := NewFrame()
+	for x := 0; x < 60; x++ {
+		f.SetBlock(x, 0, 0, Block{uint(1 + x%3), 0})
+	}
+	var charge [60]float64
+	for i := 0; i < 10; i++ {
+		g := NewPowerGrid(f, parts)
+		for j := 0; j < 7; j++ {
+			g.Tick(0.37)
+		}
+		g.Detach()
+		for x := range charge {
+			if i == 0 {
+				charge[x] = g.Charge(x, 0, 0)
+			} else if g.Charge(x, 0, 0) != charge[x] {
+				t.Fatalf("Tick charged battery at %d to %v, then to %v", x, charge[x], g.Charge(x, 0, 0))
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Renderer draws meshes. Begin starts an image with a projection matrix,
+// Draw adds a mesh seen through a modelview matrix, and End completes the
+// image, returning the first error while drawing it. Matrices are stored
+// row by row, as by Matrix4.LoadSQT.
+type Renderer interface {
+	Begin(projection *Matrix4)
+	Draw(mesh *Mesh, modelview *Matrix4)
+	End() error
+}
+
+// Colours of the background and of surfaces, matching main.go and basic.fs.
+var (
+	clearColour   = color.RGBA{51, 51, 51, 255}
+	surfaceColour = color.RGBA{255, 255, 255, 255}
+)
+
+// SoftwareRenderer is a Renderer which rasterizes meshes into an image in
+// pure Go, following the same rules as the OpenGL renderer: triangles
+// wound counter-clockwise on screen face the front, back faces are
+// discarded as by basic.fs, and nearer surfaces hide farther ones.
+type SoftwareRenderer struct {
+	Image      *image.RGBA
+	depth      []float64
+	projection Matrix4
+}
+
+// NewSoftwareRenderer creates a renderer drawing into a width by height
+// image.
+func NewSoftwareRenderer(width, height int) *SoftwareRenderer {
+	return &SoftwareRenderer{
+		Image: image.NewRGBA(image.Rect(0, 0, width, height)),
+		depth: make([]float64, width*height),
+	}
+}
+
+// Begin clears the image and depth buffer and sets the projection.
+func (r *SoftwareRenderer) Begin(projection *Matrix4) {
+	r.projection = *projection
+	for i := range r.depth {
+		r.depth[i] = 1
+	}
+	b := r.Image.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r.Image.SetRGBA(x, y, clearColour)
+		}
+	}
+}
+
+// Draw rasterizes the triangles of mesh.
+func (r *SoftwareRenderer) Draw(mesh *Mesh, modelview *Matrix4) {
+	m := r.projection.Multiply(modelview)
+	clip := make([][4]float64, len(mesh.Vertices)/3)
+	for i := range clip {
+		v := m.Transform([4]float32{mesh.Vertices[3*i], mesh.Vertices[3*i+1], mesh.Vertices[3*i+2], 1})
+		clip[i] = [4]float64{float64(v[0]), float64(v[1]), float64(v[2]), float64(v[3])}
+	}
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		tri := [][4]float64{clip[mesh.Indices[i]], clip[mesh.Indices[i+1]], clip[mesh.Indices[i+2]]}
+		poly := clipNear(tri)
+		for j := 1; j+1 < len(poly); j++ {
+			r.triangle(poly[0], poly[j], poly[j+1])
+		}
+	}
+}
+
+// End does nothing, as the image is complete after each Draw.
+func (r *SoftwareRenderer) End() error {
+	return nil
+}
+
+// clipNear clips the polygon poly in clip coordinates against the near
+// plane, z = -w, so that every vertex left is in front of the camera.
+func clipNear(poly [][4]float64) (out [][4]float64) {
+	dist := func(v [4]float64) float64 { return v[2] + v[3] }
+	for i, a := range poly {
+		b := poly[(i+1)%len(poly)]
+		da, db := dist(a), dist(b)
+		if da >= 0 {
+			out = append(out, a)
+		}
+		if da >= 0 != (db >= 0) {
+			t := da / (da - db)
+			var v [4]float64
+			for k := range v {
+				v[k] = a[k] + (b[k]-a[k])*t
+			}
+			out = append(out, v)
+		}
+	}
+	return
+}
+
+// triangle rasterizes the triangle with vertices in clip coordinates a, b
+// and c, if it faces the front. A pixel is covered when its centre lies
+// inside the triangle or on a top or left edge, so that triangles sharing
+// an edge never both cover a pixel.
+func (r *SoftwareRenderer) triangle(a, b, c [4]float64) {
+	bounds := r.Image.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+
+	// Window coordinates, with y increasing upwards as in OpenGL
+	var p [3][3]float64
+	for i, v := range [3][4]float64{a, b, c} {
+		p[i] = [3]float64{
+			(v[0]/v[3] + 1) / 2 * w,
+			(v[1]/v[3] + 1) / 2 * h,
+			v[2] / v[3],
+		}
+	}
+
+	area := edge(p[0], p[1], p[2])
+	if area <= 0 {
+		return // back facing or degenerate
+	}
+
+	minX := math.Max(0, math.Floor(math.Min(p[0][0], math.Min(p[1][0], p[2][0]))))
+	maxX := math.Min(w-1, math.Ceil(math.Max(p[0][0], math.Max(p[1][0], p[2][0]))))
+	minY := math.Max(0, math.Floor(math.Min(p[0][1], math.Min(p[1][1], p[2][1]))))
+	maxY := math.Min(h-1, math.Ceil(math.Max(p[0][1], math.Max(p[1][1], p[2][1]))))
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			q := [3]float64{x + 0.5, y + 0.5, 0}
+			w0 := edge(p[1], p[2], q)
+			w1 := edge(p[2], p[0], q)
+			w2 := edge(p[0], p[1], q)
+			if !covers(w0, p[1], p[2]) || !covers(w1, p[2], p[0]) || !covers(w2, p[0], p[1]) {
+				continue
+			}
+
+			z := (w0*p[0][2] + w1*p[1][2] + w2*p[2][2]) / area
+			if z < -1 || z > 1 {
+				continue
+			}
+			i := int(x) + (int(h)-1-int(y))*int(w)
+			if z >= r.depth[i] {
+				continue
+			}
+			r.depth[i] = z
+			r.Image.SetRGBA(bounds.Min.X+int(x), bounds.Min.Y+int(h)-1-int(y), surfaceColour)
+		}
+	}
+}
+
+// edge returns twice the signed area of the triangle a, b, c, which is
+// positive when they are counter-clockwise.
+func edge(a, b, c [3]float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// covers applies the top-left rule to the edge function e of the edge from
+// a to b of a counter-clockwise triangle.
+func covers(e float64, a, b [3]float64) bool {
+	if e != 0 {
+		return e > 0
+	}
+	dy := b[1] - a[1]
+	return dy < 0 || dy == 0 && b[0] < a[0]
+}
+
+// CubeMesh returns the mesh of a single voxel at the origin.
+func CubeMesh() *Mesh {
+	var c chunk
+	c[0][0][0] = Block{Id: 1}
+	return buildMesh(&c, &[6]*chunk{}, [3]int{}, DefaultBlocks)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden images in testdata")
+
+// checkGolden compares img with the golden image testdata/name.png, or
+// rewrites the golden image when the -update flag is set.
+func checkGolden(t *testing.T, name string, img *image.RGBA) {
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		if err := png.Encode(file, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	golden, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("%s rendered at size %v, golden image is %v", name, img.Bounds(), golden.Bounds())
+	}
+	diff := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r0, g0, b0, a0 := img.At(x, y).RGBA()
+			r1, g1, b1, a1 := golden.At(x, y).RGBA()
+			if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		t.Errorf("%s differs from golden image in %d pixels", name, diff)
+	}
+}
+
+// countSurface returns the number of pixels covered by surfaces.
+func countSurface(img *image.RGBA) (n int) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i] == surfaceColour.R {
+			n++
+		}
+	}
+	return
+}
+
+// renderView renders meshes with a camera at eye looking at centre.
+func renderView(meshes []*Mesh, eye, centre Vec3) *image.RGBA {
+	r := NewSoftwareRenderer(64, 48)
+	var proj, view Matrix4
+	proj.LoadPerspective(math.Pi/3, 4.0/3.0, 0.1, 100)
+	view.LoadLookAt(eye, centre, Vec3{0, 1, 0})
+	r.Begin(&proj)
+	for _, m := range meshes {
+		r.Draw(m, &view)
+	}
+	r.End()
+	return r.Image
+}
+
+func TestRasterCube(t *testing.T) {
+	cube := []*Mesh{CubeMesh()}
+	img := renderView(cube, Vec3{2.5, 2, 3}, Vec3{0.5, 0.5, 0.5})
+	checkGolden(t, "cube", img)
+
+	// From inside the cube every face is seen from behind and discarded
+	img = renderView(cube, Vec3{0.5, 0.5, 0.5}, Vec3{0.5, 0.5, 0})
+	if n := countSurface(img); n != 0 {
+		t.Errorf("Draw drew %d pixels of back faces", n)
+	}
+
+	// Looking straight at a face, it covers the middle of the view
+	img = renderView(cube, Vec3{0.5, 0.5, 3}, Vec3{0.5, 0.5, 0.5})
+	if img.RGBAAt(32, 24) != surfaceColour || img.RGBAAt(1, 1) != clearColour {
+		t.Error("Draw did not draw cube face in the centre of the view")
+	}
+}
+
+func TestRasterChunk(t *testing.T) {
+	f := NewFrame()
+	for x := 0; x < 4; x++ {
+		for z := 0; z < 4; z++ {
+			for y := 0; y <= (x+z)%3; y++ {
+				f.SetBlock(x, y, z, Block{1, 0})
+			}
+		}
+	}
+	f.SetBlock(-1, 0, 0, Block{2, 0})
+	var meshes []*Mesh
+	for _, p := range f.chunkPositions() {
+		meshes = append(meshes, f.Mesh(p))
+	}
+	img := renderView(meshes, Vec3{-4, 6, 9}, Vec3{2, 0, 2})
+	checkGolden(t, "chunk", img)
+}
+
+func TestRasterClipping(t *testing.T) {
+	// A floor passing under the camera is clipped at the near plane
+	// rather than wrapping round behind it
+	f := NewFrame()
+	for x := -8; x < 8; x++ {
+		for z := -8; z < 8; z++ {
+			f.SetBlock(x, 0, z, Block{1, 0})
+		}
+	}
+	var meshes []*Mesh
+	for _, p := range f.chunkPositions() {
+		meshes = append(meshes, f.Mesh(p))
+	}
+	img := renderView(meshes, Vec3{0, 2, 0}, Vec3{0, 1.5, -1})
+	for x := 0; x < 64; x++ {
+		if img.RGBAAt(x, 0) != clearColour {
+			t.Fatal("Draw drew floor above the horizon")
+		}
+		if img.RGBAAt(x, 47) != surfaceColour {
+			t.Fatal("Draw did not draw floor below the camera")
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"math"
+)
+
+// RayHit describes the voxel struck by a ray cast into a Frame.
+type RayHit struct {
+	Block      Block
+	X, Y, Z    int     // local voxel coordinates of the block hit
+	Nx, Ny, Nz int     // local normal of the face hit, zero if the ray started inside the block
+	Distance   float64 // world space distance from the ray origin to the face hit
+}
+
+// Raycast casts a ray from the world space point origin in the direction
+// dir and returns the first non-empty Block it hits within maxDist,
+// walking the voxels of the frame with a DDA traversal. The ray is mapped
+// into the frame's local coordinates through the inverse of its world
+// transform, so it works for rotated and scaled frames and frames attached
+// to other frames. Only the part of the ray inside the bounding box of the
+// frame's chunks is walked. ok is false if nothing was hit, or if maxDist
+// is not a positive, finite distance.
+func (f *Frame) Raycast(origin, dir Vec3, maxDist float64) (hit RayHit, ok bool) {
+	if dir == (Vec3{}) || !(maxDist > 0) || math.IsInf(maxDist, 1) || len(f.chunks) == 0 {
+		return
+	}
+	lo, hi := f.voxelBounds()
+
+	// Since the transformation is affine, a world space distance t along
+	// the normalised ray is also the parameter of the local ray.
+	inv := f.world().Inverse()
+	lp := inv.TransformAbs(origin)
+	ld := inv.TransformRel(dir.Normalise())
+	o := [3]float64{lp.X, lp.Y, lp.Z}
+	d := [3]float64{ld.X, ld.Y, ld.Z}
+
+	// Clip the ray to the bounding box
+	enter, exit, axis := 0.0, maxDist, -1
+	for i := 0; i < 3; i++ {
+		a, b := float64(lo[i]), float64(hi[i]+1)
+		if d[i] == 0 {
+			if o[i] < a || o[i] >= b {
+				return
+			}
+			continue
+		}
+		t0, t1 := (a-o[i])/d[i], (b-o[i])/d[i]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > enter {
+			enter, axis = t0, i
+		}
+		exit = math.Min(exit, t1)
+	}
+	if enter > exit {
+		return
+	}
+
+	var v, step [3]int
+	var tMax, tDelta [3]float64
+	for i := 0; i < 3; i++ {
+		v[i] = int(math.Floor(o[i] + d[i]*enter))
+		if v[i] < lo[i] {
+			v[i] = lo[i]
+		}
+		if v[i] > hi[i] {
+			v[i] = hi[i]
+		}
+		switch {
+		case d[i] > 0:
+			step[i] = 1
+			tMax[i] = (float64(v[i]+1) - o[i]) / d[i]
+			tDelta[i] = 1 / d[i]
+		case d[i] < 0:
+			step[i] = -1
+			tMax[i] = (o[i] - float64(v[i])) / -d[i]
+			tDelta[i] = 1 / -d[i]
+		default:
+			tMax[i] = math.Inf(1)
+			tDelta[i] = math.Inf(1)
+		}
+	}
+
+	if b := f.Block(v[0], v[1], v[2]); !b.IsEmpty() {
+		hit = RayHit{Block: b, X: v[0], Y: v[1], Z: v[2], Distance: enter}
+		if axis >= 0 {
+			hit.setNormal(axis, step[axis])
+		}
+		return hit, true
+	}
+
+	for {
+		a := 0
+		if tMax[1] < tMax[a] {
+			a = 1
+		}
+		if tMax[2] < tMax[a] {
+			a = 2
+		}
+
+		t := tMax[a]
+		if t > exit {
+			return
+		}
+		v[a] += step[a]
+		tMax[a] += tDelta[a]
+		if v[a] < lo[a] || v[a] > hi[a] {
+			return
+		}
+
+		if b := f.Block(v[0], v[1], v[2]); !b.IsEmpty() {
+			hit = RayHit{Block: b, X: v[0], Y: v[1], Z: v[2], Distance: t}
+			hit.setNormal(a, step[a])
+			return hit, true
+		}
+	}
+}
+
+// setNormal sets the normal of the hit to face back along axis a against
+// a ray stepping by step along it.
+func (hit *RayHit) setNormal(a, step int) {
+	switch a {
+	case 0:
+		hit.Nx = -step
+	case 1:
+		hit.Ny = -step
+	case 2:
+		hit.Nz = -step
+	}
+}
+
+// voxelBounds returns the smallest and largest local voxel coordinates of
+// the frame's chunks. The frame must have at least one chunk.
+func (f *Frame) voxelBounds() (lo, hi [3]int) {
+	first := true
+	for p := range f.chunks {
+		c := [3]int{p.x, p.y, p.z}
+		for i := range c {
+			l, h := c[i]*chunkDims[i], c[i]*chunkDims[i]+chunkDims[i]-1
+			if first || l < lo[i] {
+				lo[i] = l
+			}
+			if first || h > hi[i] {
+				hi[i] = h
+			}
+		}
+		first = false
+	}
+	return
+}
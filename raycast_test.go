@@ -0,0 +1,134 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRaycast(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(5, 0, 0, Block{3, 0})
+
+	hit, ok := f.Raycast(Vec3{0.5, 0.5, 0.5}, Vec3{1, 0, 0}, 100)
+	checkHit(t, "along +x", hit, ok, 3, 5, 0, 0, -1, 0, 0, 4.5)
+
+	if _, ok := f.Raycast(Vec3{0.5, 0.5, 0.5}, Vec3{1, 0, 0}, 4); ok {
+		t.Error("Raycast hit block beyond maxDist")
+	}
+	if _, ok := f.Raycast(Vec3{0.5, 0.5, 0.5}, Vec3{-1, 0, 0}, 100); ok {
+		t.Error("Raycast hit block behind ray")
+	}
+	if _, ok := f.Raycast(Vec3{0.5, 1.5, 0.5}, Vec3{1, 0, 0}, 100); ok {
+		t.Error("Raycast hit block beside ray")
+	}
+	if _, ok := f.Raycast(Vec3{0.5, 0.5, 0.5}, Vec3{0, 0, 0}, 100); ok {
+		t.Error("Raycast hit block with zero direction")
+	}
+
+	hit, ok = f.Raycast(Vec3{5.5, 0.5, 0.5}, Vec3{0, 1, 0}, 100)
+	checkHit(t, "from inside block", hit, ok, 3, 5, 0, 0, 0, 0, 0, 0)
+
+	hit, ok = f.Raycast(Vec3{5.5, 10, 0.5}, Vec3{0, -2, 0}, 100)
+	checkHit(t, "along -y", hit, ok, 3, 5, 0, 0, 0, 1, 0, 9)
+}
+
+func TestRaycastBounds(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(16, 0, 0, Block{1, 0})
+	f.SetBlock(31, 5, 0, Block{2, 0})
+
+	// Rays from outside the frame start where they enter its chunks
+	hit, ok := f.Raycast(Vec3{-50, 0.5, 0.5}, Vec3{1, 0, 0}, 100)
+	checkHit(t, "entering -x face", hit, ok, 1, 16, 0, 0, -1, 0, 0, 66)
+	hit, ok = f.Raycast(Vec3{100, 0.5, 0.5}, Vec3{-1, 0, 0}, 100)
+	checkHit(t, "crossing chunk", hit, ok, 1, 16, 0, 0, 1, 0, 0, 83)
+	hit, ok = f.Raycast(Vec3{100, 5.5, 0.5}, Vec3{-1, 0, 0}, 100)
+	checkHit(t, "entering +x face", hit, ok, 2, 31, 5, 0, 1, 0, 0, 68)
+	if _, ok := f.Raycast(Vec3{100, 5.5, 0.5}, Vec3{-1, 0, 0}, 67); ok {
+		t.Error("Raycast hit block beyond maxDist outside chunks")
+	}
+	if _, ok := f.Raycast(Vec3{20.5, 20.5, 0.5}, Vec3{1, 0, 0}, 100); ok {
+		t.Error("Raycast hit block with ray missing chunks")
+	}
+
+	// Distances which never end return without walking
+	for _, d := range []float64{math.Inf(1), math.NaN(), 0, -1} {
+		if _, ok := f.Raycast(Vec3{-50, 0.5, 0.5}, Vec3{1, 0, 0}, d); ok {
+			t.Errorf("Raycast hit block with maxDist %v", d)
+		}
+	}
+	if _, ok := f.Raycast(Vec3{20.5, 0.5, 0.5}, Vec3{1, 0, 0}, 1e12); ok {
+		t.Error("Raycast hit block leaving chunks")
+	}
+	if _, ok := NewFrame().Raycast(Vec3{}, Vec3{1, 0, 0}, 1e12); ok {
+		t.Error("Raycast hit block in empty frame")
+	}
+}
+
+func TestRaycastNegative(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(-17, -1, -20, Block{1, 0})
+
+	hit, ok := f.Raycast(Vec3{-16.5, -0.5, 0.5}, Vec3{0, 0, -1}, 100)
+	checkHit(t, "along -z", hit, ok, 1, -17, -1, -20, 0, 0, 1, 19.5)
+
+	f.SetBlock(-3, -3, -3, Block{2, 0})
+	hit, ok = f.Raycast(Vec3{0, 0, 0}, Vec3{-1, -1, -1}, 100)
+	if !ok || hit.Block.Id != 2 || hit.X != -3 || hit.Y != -3 || hit.Z != -3 {
+		t.Error("Raycast did not hit (-3, -3, -3) along the diagonal")
+	} else if math.Abs(hit.Distance-2*math.Sqrt(3)) > 1e-12 {
+		t.Errorf("Raycast returned distance %v along the diagonal, expected %v", hit.Distance, 2*math.Sqrt(3))
+	}
+}
+
+func TestRaycastTransformed(t *testing.T) {
+	f := NewFrame()
+	f.Transform.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{0, 0, 1}))
+	f.Transform.SetScale(2)
+	f.Transform.SetTranslation(Vec3{10, 0, 0})
+	f.SetBlock(1, 0, 0, Block{4, 0})
+
+	// Local +x maps to world +y, so the ray travels along local -x and
+	// enters the block through its +x face at world (9, 4, 1).
+	hit, ok := f.Raycast(Vec3{9, 10, 1}, Vec3{0, -1, 0}, 100)
+	checkHit(t, "rotated and scaled frame", hit, ok, 4, 1, 0, 0, 1, 0, 0, 6)
+
+	if _, ok := f.Raycast(Vec3{9, 10, 1}, Vec3{0, -1, 0}, 5.9); ok {
+		t.Error("Raycast hit block beyond maxDist in rotated and scaled frame")
+	}
+}
+
+func checkHit(
+	t *testing.T, desc string, hit RayHit, ok bool,
+	id uint, x, y, z int, nx, ny, nz int, dist float64,
+) {
+	if !ok {
+		t.Error("Raycast did not hit anything " + desc)
+		return
+	}
+	if hit.Block.Id != id {
+		t.Errorf("Raycast hit block %d %s, expected %d", hit.Block.Id, desc, id)
+	}
+	if hit.X != x || hit.Y != y || hit.Z != z {
+		t.Errorf("Raycast hit (%d, %d, %d) %s, expected (%d, %d, %d)", hit.X, hit.Y, hit.Z, desc, x, y, z)
+	}
+	if hit.Nx != nx || hit.Ny != ny || hit.Nz != nz {
+		t.Errorf("Raycast hit face (%d, %d, %d) %s, expected (%d, %d, %d)", hit.Nx, hit.Ny, hit.Nz, desc, nx, ny, nz)
+	}
+	if math.Abs(hit.Distance-dist) > 1e-12 {
+		t.Errorf("Raycast returned distance %v %s, expected %v", hit.Distance, desc, dist)
+	}
+}
+
+func TestRaycastChild(t *testing.T) {
+	parent := NewFrame()
+	parent.Transform.SetTranslation(Vec3{0, 0, 20})
+
+	f := NewFrame()
+	f.SetParent(parent)
+	f.Transform.SetTranslation(Vec3{0, 0, 0})
+	f.SetBlock(0, 0, 0, Block{5, 0})
+
+	hit, ok := f.Raycast(Vec3{0.5, 0.5, 0}, Vec3{0, 0, 1}, 100)
+	checkHit(t, "in child frame", hit, ok, 5, 0, 0, 0, 0, 0, -1, 20)
+}
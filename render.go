@@ -1,8 +1,6 @@
 package main
 
 import (
-	"fmt"
-
 	"github.com/go-gl/gl"
 )
 
@@ -10,79 +8,6 @@ const (
 	POSITION = 0
 )
 
-var (
-	CUBE_VERTICES [3*4*6]float32 = [3*4*6]float32{
-		0., 0., 0.,	//front
-		0., 1., 0.,
-		1., 1., 0.,
-		1., 0., 0.,
-
-		0., 0., 0.,	//bottom
-		1., 0., 0.,
-		1., 0., 1.,
-		0., 0., 1.,
-
-		0., 0., 0.,	//left side
-		0., 0., 1.,
-		0., 1., 1.,
-		0., 1., 0.,
-
-		1., 1., 1.,	//back
-		0., 1., 1.,
-		0., 0., 1.,
-		1., 0., 1.,
-
-		1., 1., 1.,	//top
-		1., 1., 0.,
-		0., 1., 0.,
-		0., 1., 1.,
-
-		1., 1., 1.,	//right side
-		1., 0., 1.,
-		1., 0., 0.,
-		1., 1., 0.,
-	}
-	CUBE_COLORS [3*4*6]float32 = [3*4*6]float32{
-		1., 0., 0.,	//red
-		1., 0., 0.,
-		1., 0., 0.,
-		1., 0., 0.,
-
-		1., 1., 0.,	//yellow
-		1., 1., 0.,
-		1., 1., 0.,
-		1., 1., 0.,
-
-		0., 0., 1.,	//blue
-		0., 0., 1.,
-		0., 0., 1.,
-		0., 0., 1.,
-
-		1., 0., 0.,	//red
-		1., 0., 0.,
-		1., 0., 0.,
-		1., 0., 0.,
-
-		1., 1., 0.,	//yellow
-		1., 1., 0.,
-		1., 1., 0.,
-		1., 1., 0.,
-
-		0., 0., 1.,	//blue
-		0., 0., 1.,
-		0., 0., 1.,
-		0., 0., 1.,
-	}
-	CUBE_INDICES [4*6]uint = [4*6]uint{
-		0, 1, 2, 3,
-		4, 5, 6, 7,
-		8, 9, 10, 11,
-		12,13,14,15,
-		16,17,18,19,
-		20,21,22,23,
-	}
-)
-
 // Model represents a renderable 3D object stored in OpenGL.
 type Model struct {
 	numIndices int
@@ -90,67 +15,142 @@ type Model struct {
 	buffers []gl.Buffer
 }
 
-func err() {
-	if e := gl.GetError(); e != gl.NO_ERROR {
-		fmt.Print("Error: ")
-		switch {
-		case e == gl.INVALID_ENUM:
-			fmt.Println("INVALID_ENUM")
-		case e == gl.INVALID_VALUE:
-			fmt.Println("INVALID_VALUE")
-		case e == gl.INVALID_OPERATION:
-			fmt.Println("INVALID_OPERATION")
-		case e == gl.STACK_OVERFLOW:
-			fmt.Println("STACK_OVERFLOW")
-		case e == gl.STACK_UNDERFLOW:
-			fmt.Println("STACK_UNDERFLOW")
-		case e == gl.OUT_OF_MEMORY:
-			fmt.Println("OUT_OF_MEMORY")
-		case e == gl.TABLE_TOO_LARGE:
-			fmt.Println("TABLE_TOO_LARGE")
-		default:
-			fmt.Println("UNDEFINED")
-		}
-	}
+// glCheck checks for OpenGL errors after op, as set by GLCheck, recording
+// the call site of glCheck.
+func glCheck(op string) error {
+	return checkErrors(op, 1, func() uint32 { return uint32(gl.GetError()) })
+}
+
+// Render draws the model using OpenGL.
+func (m Model) Render() error {
+	m.vao[POSITION].Bind()
+	m.buffers[1].Bind(gl.ELEMENT_ARRAY_BUFFER)
+	gl.DrawElements(gl.TRIANGLES, m.numIndices, gl.UNSIGNED_INT, nil)
+	return glCheck("drawing model")
 }
 
-// NewModel creates a simple cube model.
-func NewModel(program gl.Program) (m Model) {
-	
+// Delete frees the buffers of the model.
+func (m Model) Delete() {
+	gl.DeleteBuffers(m.buffers)
+	gl.DeleteVertexArrays(m.vao)
+}
+
+// NewChunkModel creates a model from the mesh of a chunk. Only positions
+// are uploaded, as basic.vs does not use the normals or block Ids.
+func NewChunkModel(mesh *Mesh) (m Model, e error) {
 	m.vao = new([1]gl.VertexArray)[:]
-	
+
 	gl.GenVertexArrays(m.vao)
 	m.vao[POSITION].Bind()
-	vertLoc := program.GetAttribLocation("a_position")
-	
+
+	// Vertex and index buffers
 	m.buffers = make([]gl.Buffer, 2, 2)
 	gl.GenBuffers(m.buffers)
-	
-	
-	// Vertex buffer
-	m.buffers[0].Bind(gl.ARRAY_BUFFER)
-	gl.BufferData(gl.ARRAY_BUFFER, 3*4*6*4, &CUBE_VERTICES, gl.STATIC_DRAW);
-	vertLoc.EnableArray();
-	vertLoc.AttribPointer(3, gl.FLOAT, false, 0, nil);
-	//vertLoc.DisableArray();
-	
+
+	m.numIndices = len(mesh.Indices)
+	if m.numIndices == 0 {
+		return
+	}
+
+	attribData("a_position", m.buffers[0], 4*len(mesh.Vertices), &mesh.Vertices[0], 3, gl.FLOAT)
+
 	// Index buffer
-	m.numIndices = len(CUBE_INDICES)
 	m.buffers[1].Bind(gl.ELEMENT_ARRAY_BUFFER)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*m.numIndices, &CUBE_INDICES, gl.STATIC_DRAW)
-	
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*m.numIndices, &mesh.Indices[0], gl.STATIC_DRAW)
+
 	m.buffers[0].Unbind(gl.ARRAY_BUFFER)
 	m.buffers[1].Unbind(gl.ELEMENT_ARRAY_BUFFER)
 
-	err()
-
+	if e = glCheck("creating chunk model"); e != nil {
+		m.Delete()
+	}
 	return
 }
 
-// Render draws the model using OpenGL.
-func (m Model) Render() {
-	m.vao[POSITION].Bind()
-	m.buffers[1].Bind(gl.ELEMENT_ARRAY_BUFFER)
-	gl.DrawElements(gl.TRIANGLES, m.numIndices, gl.UNSIGNED_INT, nil)
-	err()
+// attribData uploads size bytes of data into buffer and points the named
+// vertex attribute at it, using the location fixed by attribLocations so
+// that the model works with any ShaderProgram.
+func attribData(name string, buffer gl.Buffer, size int, data interface{}, components uint, typ gl.GLenum) {
+	buffer.Bind(gl.ARRAY_BUFFER)
+	gl.BufferData(gl.ARRAY_BUFFER, size, data, gl.STATIC_DRAW)
+	loc := attribLocations[name]
+	loc.EnableArray()
+	loc.AttribPointer(components, typ, false, 0, nil)
+}
+
+// GLRenderer is a Renderer which draws with OpenGL, uploading each mesh
+// the first time it is drawn. The first error while drawing an image is
+// returned by End.
+type GLRenderer struct {
+	shader *ShaderProgram
+	models map[*Mesh]Model
+	err    error
+}
+
+// NewGLRenderer creates a renderer drawing with shader, which must have
+// the uniforms of basic.vs.
+func NewGLRenderer(shader *ShaderProgram) *GLRenderer {
+	return &GLRenderer{
+		shader: shader,
+		models: make(map[*Mesh]Model),
+	}
+}
+
+// Begin clears the window and sets the projection.
+func (r *GLRenderer) Begin(projection *Matrix4) {
+	gl.Enable(gl.DEPTH_TEST)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	r.shader.Use()
+
+	// Matrix4 is stored row by row, so OpenGL must transpose it
+	a := projection.Array()
+	r.shader.Uniform("projection_matrix").UniformMatrix4f(true, &a)
+	r.fail(glCheck("starting image"))
+}
+
+// Draw draws mesh, uploading it first if it is new.
+func (r *GLRenderer) Draw(mesh *Mesh, modelview *Matrix4) {
+	if e := r.Upload(mesh); e != nil {
+		r.fail(e)
+		return
+	}
+	model := r.models[mesh]
+	a := modelview.Array()
+	r.shader.Uniform("modelview_matrix").UniformMatrix4f(true, &a)
+	r.fail(model.Render())
+}
+
+// Upload uploads mesh ahead of drawing it, implementing MeshUploader.
+func (r *GLRenderer) Upload(mesh *Mesh) error {
+	if _, ok := r.models[mesh]; ok {
+		return nil
+	}
+	model, e := NewChunkModel(mesh)
+	if e != nil {
+		return e
+	}
+	r.models[mesh] = model
+	return nil
+}
+
+// Free deletes the uploaded model of mesh, implementing MeshUploader.
+func (r *GLRenderer) Free(mesh *Mesh) {
+	if model, ok := r.models[mesh]; ok {
+		model.Delete()
+		delete(r.models, mesh)
+	}
+}
+
+// End returns the first error since Begin.
+func (r *GLRenderer) End() error {
+	e := r.err
+	r.err = nil
+	return e
+}
+
+// fail records e if it is the first error of the image.
+func (r *GLRenderer) fail(e error) {
+	if r.err == nil {
+		r.err = e
+	}
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ResourceDef describes one kind of resource, such as the yield of a mined
+// block.
+type ResourceDef struct {
+	Name   string
+	Mass   float64 // mass of a single unit
+	Volume float64 // space taken by a single unit
+}
+
+// ResourceRegistry maps resource names to their definitions.
+type ResourceRegistry struct {
+	defs map[string]ResourceDef
+}
+
+// DefaultResources is the registry consulted by inventories that do not
+// have their own.
+var DefaultResources = NewResourceRegistry()
+
+// NewResourceRegistry creates an empty registry.
+func NewResourceRegistry() *ResourceRegistry {
+	return &ResourceRegistry{make(map[string]ResourceDef)}
+}
+
+// Register adds a resource definition to the registry. Names must be
+// unique and not empty.
+func (r *ResourceRegistry) Register(def ResourceDef) error {
+	if def.Name == "" {
+		return fmt.Errorf("resource has no name")
+	}
+	if _, ok := r.defs[def.Name]; ok {
+		return fmt.Errorf("resource %q already registered", def.Name)
+	}
+	if def.Mass < 0 || def.Volume < 0 {
+		return fmt.Errorf("resource %q has negative mass or volume", def.Name)
+	}
+	r.defs[def.Name] = def
+	return nil
+}
+
+// Def returns the definition of the named resource. Unregistered resources
+// are treated as having a mass and volume of one.
+func (r *ResourceRegistry) Def(name string) ResourceDef {
+	if def, ok := r.defs[name]; ok {
+		return def
+	}
+	return ResourceDef{Name: name, Mass: 1, Volume: 1}
+}
+
+// resourceDefFile is the representation of a ResourceDef in a data file,
+// where resources have a mass and volume of one unless stated otherwise.
+type resourceDefFile struct {
+	Name   string   `json:"name"`
+	Mass   *float64 `json:"mass"`
+	Volume *float64 `json:"volume"`
+}
+
+// Load reads a JSON array of resource definitions from rd and registers
+// them.
+func (r *ResourceRegistry) Load(rd io.Reader) error {
+	var defs []resourceDefFile
+	if err := json.NewDecoder(rd).Decode(&defs); err != nil {
+		return fmt.Errorf("reading resource definitions: %w", err)
+	}
+
+	for _, d := range defs {
+		def := ResourceDef{Name: d.Name, Mass: 1, Volume: 1}
+		if d.Mass != nil {
+			def.Mass = *d.Mass
+		}
+		if d.Volume != nil {
+			def.Volume = *d.Volume
+		}
+		if err := r.Register(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFile reads resource definitions from the named JSON file.
+func (r *ResourceRegistry) LoadFile(name string) error {
+	return loadFile(name, r.Load)
+}
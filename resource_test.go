@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResourceRegistry(t *testing.T) {
+	r := NewResourceRegistry()
+	if def := r.Def("iron"); def.Mass != 1 || def.Volume != 1 {
+		t.Error("Def did not return default for unregistered resource")
+	}
+	if err := r.Register(ResourceDef{"iron", 5, 0.5}); err != nil {
+		t.Error("Register returned error: " + err.Error())
+	}
+	if def := r.Def("iron"); def.Mass != 5 || def.Volume != 0.5 {
+		t.Error("Def did not return registered definition")
+	}
+	if r.Register(ResourceDef{"iron", 1, 1}) == nil {
+		t.Error("Register accepted duplicate name")
+	}
+	if r.Register(ResourceDef{"", 1, 1}) == nil {
+		t.Error("Register accepted empty name")
+	}
+	if r.Register(ResourceDef{"antimatter", -1, 1}) == nil {
+		t.Error("Register accepted negative mass")
+	}
+}
+
+func TestResourceRegistryLoad(t *testing.T) {
+	r := NewResourceRegistry()
+	err := r.Load(strings.NewReader(`[
+		{"name": "iron", "mass": 5, "volume": 0.5},
+		{"name": "water"}
+	]`))
+	if err != nil {
+		t.Fatal("Load returned error: " + err.Error())
+	}
+	if def := r.Def("iron"); def != (ResourceDef{"iron", 5, 0.5}) {
+		t.Errorf("Load returned %+v", def)
+	}
+	if def := r.Def("water"); def != (ResourceDef{"water", 1, 1}) {
+		t.Error("Load did not apply defaults")
+	}
+	for _, s := range []string{`{}`, `[{"name": "iron"}]`, `[{"name": "x", "volume": -1}]`} {
+		if r.Load(strings.NewReader(s)) == nil {
+			t.Error("Load accepted " + s)
+		}
+	}
+}
+
+func TestResourceRegistryLoadFile(t *testing.T) {
+	r := NewResourceRegistry()
+	if err := r.LoadFile("resources.json"); err != nil {
+		t.Fatal("LoadFile returned error for resources.json: " + err.Error())
+	}
+
+	// Every block yield has a definition
+	blocks := NewBlockRegistry()
+	if err := blocks.LoadFile("blocks.json"); err != nil {
+		t.Fatal(err)
+	}
+	for _, def := range blocks.defs {
+		if _, ok := r.defs[def.Yield]; def.Yield != "" && !ok {
+			t.Error("resources.json does not define " + def.Yield)
+		}
+	}
+}
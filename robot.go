@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// PartDef describes what a block contributes to a robot built from it.
+type PartDef struct {
+	Block       uint    // Id of the block
+	Thrust      float64 // force of a thruster
+	Capacity    float64 // energy held by a battery when full
+	MiningRate  float64 // hardness of rock a drill mines per second
+	Storage     float64 // volume of a cargo hold
+	SensorRange float64 // distance a sensor sees
+
+	// Power, in energy per second. Parts which produce, use or store power
+	// always conduct it.
+	Conductive bool    // carries power between its neighbours, like a wire
+	Generation float64 // power produced by a generator
+	Demand     float64 // power used by a consumer
+	Priority   int     // consumers with lower priority brown out first
+}
+
+// Conducts returns true if the part belongs to power networks.
+func (p PartDef) Conducts() bool {
+	return p.Conductive || p.Generation > 0 || p.Demand > 0 || p.Capacity > 0
+}
+
+// Parts maps block Ids to their part definitions. Blocks without one are
+// plain structure.
+type Parts map[uint]PartDef
+
+// partDefFile is the JSON form of a PartDef, naming the block as in a
+// BlockRegistry.
+type partDefFile struct {
+	Block       string  `json:"block"`
+	Thrust      float64 `json:"thrust"`
+	Capacity    float64 `json:"capacity"`
+	MiningRate  float64 `json:"mining_rate"`
+	Storage     float64 `json:"storage"`
+	SensorRange float64 `json:"sensor_range"`
+	Conductive  bool    `json:"conductive"`
+	Generation  float64 `json:"generation"`
+	Demand      float64 `json:"demand"`
+	Priority    int     `json:"priority"`
+}
+
+// LoadParts reads a JSON array of part definitions from rd, looking up
+// block names in reg.
+func LoadParts(rd io.Reader, reg *BlockRegistry) (Parts, error) {
+	var file []partDefFile
+	if err := json.NewDecoder(rd).Decode(&file); err != nil {
+		return nil, fmt.Errorf("reading part definitions: %w", err)
+	}
+
+	parts := make(Parts)
+	for _, p := range file {
+		def, ok := reg.ByName(p.Block)
+		if !ok || def.Id == 0 {
+			return nil, fmt.Errorf("part for unknown block %q", p.Block)
+		}
+		if _, ok := parts[def.Id]; ok {
+			return nil, fmt.Errorf("block %q has more than one part", p.Block)
+		}
+		if p.Thrust < 0 || p.Capacity < 0 || p.MiningRate < 0 || p.Storage < 0 || p.SensorRange < 0 ||
+			p.Generation < 0 || p.Demand < 0 {
+			return nil, fmt.Errorf("part %q has a negative property", p.Block)
+		}
+		parts[def.Id] = PartDef{
+			Block:       def.Id,
+			Thrust:      p.Thrust,
+			Capacity:    p.Capacity,
+			MiningRate:  p.MiningRate,
+			Storage:     p.Storage,
+			SensorRange: p.SensorRange,
+			Conductive:  p.Conductive,
+			Generation:  p.Generation,
+			Demand:      p.Demand,
+			Priority:    p.Priority,
+		}
+	}
+	return parts, nil
+}
+
+// LoadPartsFile reads part definitions from the named JSON file.
+func LoadPartsFile(name string, reg *BlockRegistry) (parts Parts, err error) {
+	err = loadFile(name, func(rd io.Reader) (err error) {
+		parts, err = LoadParts(rd, reg)
+		return
+	})
+	return
+}
+
+// RobotStats are the abilities of a robot, derived from the blocks of its
+// body.
+type RobotStats struct {
+	Mass        float64 // total mass of the body
+	Thrust      float64 // total force of the thrusters
+	Accel       float64 // acceleration under full thrust, zero if there is no mass
+	Capacity    float64 // energy held by the batteries when full
+	MiningRate  float64 // hardness of rock mined per second by all drills
+	Storage     float64 // total volume of the cargo holds
+	SensorRange float64 // distance seen by the furthest seeing sensor
+	Parts       int     // number of blocks which are parts
+}
+
+// Robot is a machine whose body is a frame of blocks, some of which are
+// parts such as thrusters and drills. Its stats are kept up to date as
+// blocks of the body change.
+type Robot struct {
+	Body  *Frame
+	Parts Parts
+	Cargo *Inventory // limited to the volume of the cargo holds
+	stats RobotStats
+}
+
+// NewRobot creates a robot whose body is body, with parts defined by parts.
+func NewRobot(body *Frame, parts Parts) *Robot {
+	r := &Robot{Body: body, Parts: parts, Cargo: NewInventory("robot", math.Inf(1), 0)}
+	body.Observe(r)
+	r.update()
+	return r
+}
+
+// Stats returns the current stats of the robot.
+func (r *Robot) Stats() RobotStats {
+	return r.stats
+}
+
+// BlockChanged updates the robot's stats after its body changes.
+func (r *Robot) BlockChanged(f *Frame, x, y, z int, old, b Block) {
+	r.update()
+}
+
+// Detach stops the robot following changes to its body.
+func (r *Robot) Detach() {
+	r.Body.Unobserve(r)
+}
+
+// update recalculates the robot's stats from the body's census, and fits
+// the cargo inventory to the holds. Cargo already held is kept when holds
+// are lost, but nothing more can be added until there is room again.
+func (r *Robot) update() {
+	s := RobotStats{Mass: r.Body.Mass()}
+	for id, n := range r.Body.census.totals {
+		p, ok := r.Parts[id]
+		if !ok {
+			continue
+		}
+		count := float64(n)
+		s.Thrust += p.Thrust * count
+		s.Capacity += p.Capacity * count
+		s.MiningRate += p.MiningRate * count
+		s.Storage += p.Storage * count
+		s.SensorRange = math.Max(s.SensorRange, p.SensorRange)
+		s.Parts += n
+	}
+	if s.Mass >= minMass {
+		s.Accel = s.Thrust / s.Mass
+	}
+	r.stats = s
+	r.Cargo.MaxVolume = s.Storage
+}
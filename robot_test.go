@@ -0,0 +1,172 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// robotBlocks returns a registry with structure and one block of each
+// kind of part.
+func robotBlocks() *BlockRegistry {
+	reg := NewBlockRegistry()
+	for i, name := range []string{"hull", "thruster", "battery", "drill", "sensor", "cargo hold"} {
+		reg.Register(BlockDef{Id: uint(i + 1), Name: name, Solid: true, Density: 1})
+	}
+	return reg
+}
+
+func robotParts(t *testing.T, reg *BlockRegistry) Parts {
+	parts, err := LoadParts(strings.NewReader(`[
+		{"block": "thruster", "thrust": 10},
+		{"block": "battery", "capacity": 100},
+		{"block": "drill", "mining_rate": 2},
+		{"block": "sensor", "sensor_range": 16},
+		{"block": "cargo hold", "storage": 4}
+	]`), reg)
+	if err != nil {
+		t.Fatal("LoadParts returned error: " + err.Error())
+	}
+	return parts
+}
+
+func TestRobotStats(t *testing.T) {
+	body := NewFrame()
+	body.Registry = robotBlocks()
+	body.SetBlock(0, 0, 0, Block{1, 0})
+	body.SetBlock(1, 0, 0, Block{2, 0})
+	r := NewRobot(body, robotParts(t, body.Registry))
+
+	expected := RobotStats{Mass: 2, Thrust: 10, Accel: 5, Parts: 1}
+	if s := r.Stats(); s != expected {
+		t.Errorf("NewRobot gave stats %+v, expected %+v", s, expected)
+	}
+
+	// Adding parts updates the stats straight away
+	body.SetBlock(2, 0, 0, Block{2, 0})
+	body.SetBlock(0, 1, 0, Block{3, 0})
+	body.SetBlock(0, 2, 0, Block{4, 0})
+	body.SetBlock(0, 3, 0, Block{5, 0})
+	body.SetBlock(0, 4, 0, Block{6, 0})
+	body.SetBlock(0, 5, 0, Block{6, 0})
+	expected = RobotStats{Mass: 8, Thrust: 20, Accel: 2.5, Capacity: 100, MiningRate: 2, Storage: 8, SensorRange: 16, Parts: 7}
+	if s := r.Stats(); s != expected {
+		t.Errorf("SetBlock gave stats %+v, expected %+v", s, expected)
+	}
+	if r.Cargo.MaxVolume != 8 {
+		t.Error("cargo inventory was not fitted to holds")
+	}
+
+	// Replacing and removing parts
+	body.SetBlock(1, 0, 0, Block{1, 0})
+	body.SetBlock(0, 5, 0, Block{})
+	expected = RobotStats{Mass: 7, Thrust: 10, Accel: 10.0 / 7, Capacity: 100, MiningRate: 2, Storage: 4, SensorRange: 16, Parts: 5}
+	if s := r.Stats(); s != expected {
+		t.Errorf("SetBlock gave stats %+v, expected %+v", s, expected)
+	}
+
+	// Cargo kept after losing a hold blocks further additions
+	r.Cargo.Resources = NewResourceRegistry()
+	if err := r.Cargo.Add("iron", 4, "test"); err != nil {
+		t.Fatal(err)
+	}
+	body.SetBlock(0, 4, 0, Block{})
+	if r.Cargo.Count("iron") != 4 || r.Cargo.Room("iron") != 0 {
+		t.Error("losing cargo hold did not keep cargo and leave no room")
+	}
+
+	// A detached robot no longer follows its body
+	r.Detach()
+	body.SetBlock(0, 3, 0, Block{})
+	if r.Stats().SensorRange != 16 {
+		t.Error("Detach did not stop updates")
+	}
+}
+
+func TestRobotEmptyBody(t *testing.T) {
+	r := NewRobot(NewFrame(), Parts{})
+	if s := r.Stats(); s != (RobotStats{}) {
+		t.Error("robot with empty body has stats", s)
+	}
+
+	// Stripping a body down to nothing leaves no acceleration, despite
+	// rounding in its mass
+	body := NewFrame()
+	body.Registry = robotBlocks()
+	body.Registry.Register(BlockDef{Id: 7, Name: "light hull", Solid: true, Density: 0.1})
+	r = NewRobot(body, robotParts(t, body.Registry))
+	for i := 0; i < 10; i++ {
+		body.SetBlock(i, 0, 0, Block{7, 0})
+	}
+	body.SetBlock(20, 0, 0, Block{2, 0})
+	for i := 0; i < 10; i++ {
+		body.SetBlock(i, 0, 0, Block{})
+	}
+	if s := r.Stats(); math.Abs(s.Accel-10) > 1e-9 {
+		t.Error("robot with only a thruster has stats", s)
+	}
+	body.SetBlock(20, 0, 0, Block{})
+	if s := r.Stats(); s.Accel != 0 || math.IsNaN(s.Accel) || math.IsInf(s.Accel, 0) {
+		t.Error("robot with emptied body has stats", s)
+	}
+}
+
+func TestLoadParts(t *testing.T) {
+	reg := robotBlocks()
+	parts := robotParts(t, reg)
+	if len(parts) != 5 || parts[2] != (PartDef{Block: 2, Thrust: 10}) || parts[6].Storage != 4 {
+		t.Error("LoadParts returned", parts)
+	}
+	if _, ok := parts[1]; ok {
+		t.Error("LoadParts defined part for structure")
+	}
+
+	for _, bad := range []string{
+		`{}`,
+		`[{"block": "wheel"}]`,
+		`[{"block": "empty"}]`,
+		`[{"block": "drill"}, {"block": "drill"}]`,
+		`[{"block": "thruster", "thrust": -1}]`,
+	} {
+		if _, err := LoadParts(strings.NewReader(bad), reg); err == nil {
+			t.Error("LoadParts accepted " + bad)
+		}
+	}
+}
+
+func TestLoadPartsFile(t *testing.T) {
+	reg := NewBlockRegistry()
+	if err := reg.LoadFile("blocks.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPartsFile("parts.json", reg); err != nil {
+		t.Fatal("LoadPartsFile returned error for parts.json: " + err.Error())
+	}
+}
+
+func TestLoadPartsPower(t *testing.T) {
+	reg := robotBlocks()
+	reg.Register(BlockDef{Id: 7, Name: "wire", Solid: true, Density: 1})
+	parts, err := LoadParts(strings.NewReader(`[
+		{"block": "wire", "conductive": true},
+		{"block": "drill", "demand": 5, "priority": 1},
+		{"block": "battery", "capacity": 10}
+	]`), reg)
+	if err != nil {
+		t.Fatal("LoadParts returned error: " + err.Error())
+	}
+	if p := parts[4]; p.Demand != 5 || p.Priority != 1 || p.Conductive {
+		t.Error("LoadParts returned", p)
+	}
+	for id, p := range parts {
+		if !p.Conducts() {
+			t.Errorf("part %d does not conduct", id)
+		}
+	}
+	if (PartDef{Thrust: 1}).Conducts() {
+		t.Error("unpowered part conducts")
+	}
+	if _, err := LoadParts(strings.NewReader(`[{"block": "drill", "demand": -1}]`), reg); err == nil {
+		t.Error("LoadParts accepted negative demand")
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Frames are stored as the magic string and a version number, followed by
+// the transform as eight little-endian float64s (scale, quaternion x, y, z,
+// w and translation x, y, z), the number of chunks and then each non-empty
+// chunk. A chunk is its signed varint position followed by run-length
+// encoded blocks in x, y, z order, each run being the uvarint count, Id
+// and Data.
+const (
+	frameMagic   = "DLFR"
+	frameVersion = 1
+)
+
+// ErrCorruptFrame is returned (wrapped) by ReadFrame when its input is not
+// a valid frame.
+var ErrCorruptFrame = errors.New("corrupt frame data")
+
+// countWriter counts the bytes written to the underlying io.Writer.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (n int, err error) {
+	n, err = c.w.Write(p)
+	c.n += int64(n)
+	return
+}
+
+// WriteTo writes the frame's transform and voxel data to w in a versioned
+// binary format readable by ReadFrame. It returns the number of bytes
+// written.
+func (f *Frame) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	var buf [binary.MaxVarintLen64]byte
+
+	putUvarint := func(v uint64) {
+		bw.Write(buf[:binary.PutUvarint(buf[:], v)])
+	}
+	putVarint := func(v int64) {
+		bw.Write(buf[:binary.PutVarint(buf[:], v)])
+	}
+
+	bw.WriteString(frameMagic)
+	putUvarint(frameVersion)
+
+	s := f.Transform
+	for _, v := range [...]float64{s.scale, s.q.X, s.q.Y, s.q.Z, s.q.W, s.t.X, s.t.Y, s.t.Z} {
+		binary.LittleEndian.PutUint64(buf[:8], math.Float64bits(v))
+		bw.Write(buf[:8])
+	}
+
+	// Sort the chunks so that the same frame always produces the same output
+	ps := f.chunkPositions()
+	putUvarint(uint64(len(ps)))
+	for _, p := range ps {
+		putVarint(int64(p.x))
+		putVarint(int64(p.y))
+		putVarint(int64(p.z))
+
+		c := f.chunks[p]
+		run, count := c[0][0][0], 0
+		for x := 0; x < ncx; x++ {
+			for y := 0; y < ncy; y++ {
+				for z := 0; z < ncz; z++ {
+					if c[x][y][z] == run {
+						count++
+						continue
+					}
+					putUvarint(uint64(count))
+					putUvarint(uint64(run.Id))
+					putUvarint(uint64(run.Data))
+					run, count = c[x][y][z], 1
+				}
+			}
+		}
+		putUvarint(uint64(count))
+		putUvarint(uint64(run.Id))
+		putUvarint(uint64(run.Data))
+	}
+
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// frameReader decodes the parts of a frame, recording the first error.
+type frameReader struct {
+	r       io.ByteReader
+	err     error
+	readErr error // last error from r, to tell I/O errors from bad data
+}
+
+// ReadByte reads a byte from the underlying reader, remembering its error.
+func (r *frameReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		r.readErr = err
+	}
+	return b, err
+}
+
+// decodeFail records an error from decoding a varint, which is corrupt
+// data unless the input ended or could not be read.
+func (r *frameReader) decodeFail(err error, what string) {
+	if err != r.readErr && err != io.ErrUnexpectedEOF {
+		err = fmt.Errorf("%w: %v", ErrCorruptFrame, err)
+	}
+	r.fail(err, what)
+}
+
+func (r *frameReader) fail(err error, what string) {
+	if r.err != nil {
+		return
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	r.err = fmt.Errorf("reading frame %s: %w", what, err)
+}
+
+func (r *frameReader) uvarint(what string) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(r)
+	if err != nil {
+		r.decodeFail(err, what)
+	}
+	return v
+}
+
+func (r *frameReader) varint(what string) int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(r)
+	if err != nil {
+		r.decodeFail(err, what)
+	}
+	return v
+}
+
+func (r *frameReader) float(what string) float64 {
+	var v uint64
+	for i := uint(0); i < 8 && r.err == nil; i++ {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			r.fail(err, what)
+		}
+		v |= uint64(b) << (8 * i)
+	}
+	return math.Float64frombits(v)
+}
+
+// ReadFrame reads a frame written by Frame.WriteTo from r. Corrupt input
+// produces an error wrapping ErrCorruptFrame, and truncated input one
+// wrapping io.ErrUnexpectedEOF. If r is not an io.ByteReader, ReadFrame
+// may read past the end of the frame.
+func ReadFrame(r io.Reader) (*Frame, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	fr := &frameReader{r: br}
+
+	for i := 0; i < len(frameMagic); i++ {
+		b, err := br.ReadByte()
+		if err != nil {
+			fr.fail(err, "header")
+			return nil, fr.err
+		}
+		if b != frameMagic[i] {
+			return nil, fmt.Errorf("reading frame header: %w: bad magic", ErrCorruptFrame)
+		}
+	}
+	if v := fr.uvarint("version"); fr.err == nil && v != frameVersion {
+		return nil, fmt.Errorf("reading frame header: %w: unsupported version %d", ErrCorruptFrame, v)
+	}
+
+	f := NewFrame()
+	s := f.Transform
+	for _, v := range [...]*float64{&s.scale, &s.q.X, &s.q.Y, &s.q.Z, &s.q.W, &s.t.X, &s.t.Y, &s.t.Z} {
+		*v = fr.float("transform")
+	}
+	if fr.err != nil {
+		return nil, fr.err
+	}
+	for _, v := range [...]float64{s.scale, s.q.X, s.q.Y, s.q.Z, s.q.W, s.t.X, s.t.Y, s.t.Z} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("reading frame transform: %w: non-finite value", ErrCorruptFrame)
+		}
+	}
+	if s.scale == 0 {
+		return nil, fmt.Errorf("reading frame transform: %w: zero scale", ErrCorruptFrame)
+	}
+
+	n := fr.uvarint("chunk count")
+	for i := uint64(0); i < n && fr.err == nil; i++ {
+		what := fmt.Sprintf("chunk %d", i)
+		x, y, z := fr.varint(what), fr.varint(what), fr.varint(what)
+		p := pos{int(x), int(y), int(z)}
+		if int64(p.x) != x || int64(p.y) != y || int64(p.z) != z {
+			return nil, fmt.Errorf("reading frame %s: %w: position out of range", what, ErrCorruptFrame)
+		}
+		if _, ok := f.chunks[p]; ok {
+			return nil, fmt.Errorf("reading frame %s: %w: duplicate chunk %s", what, ErrCorruptFrame, p)
+		}
+
+		var c chunk
+		for j := 0; j < ncx*ncy*ncz && fr.err == nil; {
+			count := fr.uvarint(what)
+			b := Block{uint(fr.uvarint(what)), uint(fr.uvarint(what))}
+			if fr.err != nil {
+				break
+			}
+			if count == 0 || count > uint64(ncx*ncy*ncz-j) {
+				return nil, fmt.Errorf("reading frame %s: %w: bad run length %d", what, ErrCorruptFrame, count)
+			}
+			for ; count > 0; count-- {
+				c[j/(ncy*ncz)][j/ncz%ncy][j%ncz] = b
+				j++
+			}
+		}
+		if fr.err == nil && !c.isEmpty() {
+			f.chunks[p] = c
+			f.touch(p)
+		}
+	}
+	if fr.err != nil {
+		return nil, fr.err
+	}
+	f.RecomputeMass()
+	f.recount()
+
+	return f, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSaveRoundTrip(t *testing.T) {
+	f := NewFrame()
+	f.Transform.SetRotation(QuatAxisAngle(math.Pi/3, Vec3{0, 0.6, 0.8}))
+	f.Transform.SetTranslation(Vec3{1.5, -2, 1e9})
+	f.Transform.SetScale(0.25)
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	f.SetBlock(15, 15, 15, Block{1, 7})
+	f.SetBlock(-1, -17, 40, Block{1 << 40, 3})
+	for x := 0; x < ncx; x++ {
+		for z := 0; z < ncz; z++ {
+			f.SetBlock(x-8, 100, z, Block{uint(2 + x%3), 0})
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := f.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("WriteTo returned error: " + err.Error())
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo returned %d bytes written, wrote %d", n, buf.Len())
+	}
+
+	g, err := ReadFrame(&buf)
+	if err != nil {
+		t.Fatal("ReadFrame returned error: " + err.Error())
+	}
+	if *g.Transform != *f.Transform {
+		t.Error("ReadFrame did not restore transform")
+	}
+	if len(g.chunks) != len(f.chunks) {
+		t.Errorf("ReadFrame restored %d chunks, expected %d", len(g.chunks), len(f.chunks))
+	}
+	for p, c := range f.chunks {
+		if g.chunks[p] != c {
+			t.Error("ReadFrame did not restore chunk at " + p.String())
+		}
+		if g.chunkVersion(p) == 0 {
+			t.Error("ReadFrame did not give a version to chunk at " + p.String())
+		}
+	}
+}
+
+func TestSaveEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewFrame().WriteTo(&buf); err != nil {
+		t.Fatal("WriteTo returned error: " + err.Error())
+	}
+	f, err := ReadFrame(&buf)
+	if err != nil {
+		t.Fatal("ReadFrame returned error: " + err.Error())
+	}
+	if *f.Transform != *NewSQT() || len(f.chunks) != 0 {
+		t.Error("ReadFrame did not restore empty frame")
+	}
+}
+
+func TestSaveDeterministic(t *testing.T) {
+	f := NewFrame()
+	for i := -50; i < 50; i += 7 {
+		f.SetBlock(i, -i, i*3, Block{uint(i + 100), 0})
+	}
+	var a, b bytes.Buffer
+	f.WriteTo(&a)
+	f.WriteTo(&b)
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Error("WriteTo did not produce the same output twice")
+	}
+}
+
+func TestReadFrameTruncated(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(3, -4, 5, Block{9, 1})
+	f.SetBlock(300, 0, 0, Block{2, 0})
+	var buf bytes.Buffer
+	f.WriteTo(&buf)
+	data := buf.Bytes()
+
+	for i := 0; i < len(data); i++ {
+		_, err := ReadFrame(bytes.NewReader(data[:i]))
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("ReadFrame returned %v for input truncated to %d bytes, expected unexpected EOF", err, i)
+		}
+	}
+}
+
+func TestReadFrameReadError(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(1, 2, 3, Block{4, 0})
+	var buf bytes.Buffer
+	f.WriteTo(&buf)
+
+	// Failing to read is not corrupt data
+	boom := errors.New("boom")
+	r := io.MultiReader(bytes.NewReader(buf.Bytes()[:4+1+8*8+2]), iotest.ErrReader(boom))
+	if _, err := ReadFrame(r); !errors.Is(err, boom) || errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("ReadFrame returned %v for read error", err)
+	}
+}
+
+func TestReadFrameCorrupt(t *testing.T) {
+	f := NewFrame()
+	f.SetBlock(1, 2, 3, Block{4, 0})
+	var buf bytes.Buffer
+	f.WriteTo(&buf)
+	data := buf.Bytes()
+
+	bad := append([]byte("XLFR"), data[4:]...)
+	if _, err := ReadFrame(bytes.NewReader(bad)); !errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("ReadFrame returned %v for bad magic", err)
+	}
+
+	bad = append([]byte(nil), data...)
+	bad[4] = 99
+	if _, err := ReadFrame(bytes.NewReader(bad)); !errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("ReadFrame returned %v for unsupported version", err)
+	}
+
+	// A chunk count varint which overflows 64 bits
+	bad = append([]byte(nil), data[:4+1+8*8]...)
+	bad = append(bad, bytes.Repeat([]byte{0xff}, 10)...)
+	bad = append(bad, 1)
+	if _, err := ReadFrame(bytes.NewReader(bad)); !errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("ReadFrame returned %v for overflowing varint", err)
+	}
+
+	// A single chunk at the origin whose first run of 5000 blocks is too
+	// long for it
+	bad = append([]byte(nil), data[:4+1+8*8]...)
+	bad = append(bad, 1, 0, 0, 0, 0x88, 0x27, 4, 0)
+	if _, err := ReadFrame(bytes.NewReader(bad)); !errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("ReadFrame returned %v for overlong run", err)
+	}
+
+	// Zero scale
+	bad = append([]byte(nil), data...)
+	for i := 5; i < 13; i++ {
+		bad[i] = 0
+	}
+	if _, err := ReadFrame(bytes.NewReader(bad)); !errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("ReadFrame returned %v for zero scale", err)
+	}
+
+	// Flipping any byte must not panic
+	for i := range data {
+		bad = append([]byte(nil), data...)
+		bad[i] ^= 0xff
+		ReadFrame(bytes.NewReader(bad))
+	}
+}
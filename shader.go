@@ -0,0 +1,154 @@
+package main
+
+import (
+	"io/fs"
+
+	"github.com/go-gl/gl"
+)
+
+// attribLocations fixes the location of each vertex attribute used by the
+// models in render.go, so that models stay valid when a program is
+// relinked.
+var attribLocations = map[string]gl.AttribLocation{
+	"a_position": 0,
+}
+
+// ShaderProgram is a linked vertex and fragment shader pair, loaded from
+// the files name.vs and name.fs.
+type ShaderProgram struct {
+	Program gl.Program
+	Name    string
+	Defines map[string]string // injected into both shaders
+
+	fsys     fs.FS
+	stamps   fileStamps
+	uniforms map[string]gl.UniformLocation
+	attribs  map[string]gl.AttribLocation
+}
+
+// LoadShaderProgram preprocesses, compiles and links the named shaders
+// from fsys. Compile and link failures return a *ShaderError holding the
+// log.
+func LoadShaderProgram(fsys fs.FS, name string, defines map[string]string) (*ShaderProgram, error) {
+	s := &ShaderProgram{Name: name, Defines: defines, fsys: fsys}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload builds the program again from its files. If that fails the
+// current program is kept and the error returned.
+func (s *ShaderProgram) Reload() error {
+	vsrc, err := PreprocessShader(s.fsys, s.Name+".vs", s.Defines)
+	if err != nil {
+		return err
+	}
+	fsrc, err := PreprocessShader(s.fsys, s.Name+".fs", s.Defines)
+	if err != nil {
+		return err
+	}
+	stamps := stampFiles(s.fsys, append(vsrc.Files, fsrc.Files...))
+
+	vertex, err := s.compile(gl.VERTEX_SHADER, "vertex", vsrc.Text)
+	if err != nil {
+		return err
+	}
+	defer vertex.Delete()
+	fragment, err := s.compile(gl.FRAGMENT_SHADER, "fragment", fsrc.Text)
+	if err != nil {
+		return err
+	}
+	defer fragment.Delete()
+
+	program := gl.CreateProgram()
+	program.AttachShader(vertex)
+	program.AttachShader(fragment)
+	for name, loc := range attribLocations {
+		program.BindAttribLocation(loc, name)
+	}
+	program.Link()
+	if err := glCheck("linking shader " + s.Name); err != nil {
+		program.Delete()
+		return err
+	}
+	if program.Get(gl.LINK_STATUS) == 0 {
+		log := program.GetInfoLog()
+		program.Delete()
+		return &ShaderError{s.Name, "link", log}
+	}
+	program.DetachShader(vertex)
+	program.DetachShader(fragment)
+
+	if s.Program != 0 {
+		s.Program.Delete()
+	}
+	s.Program = program
+	s.stamps = stamps
+	s.uniforms = make(map[string]gl.UniformLocation)
+	s.attribs = make(map[string]gl.AttribLocation)
+	return nil
+}
+
+// compile compiles a single shader stage.
+func (s *ShaderProgram) compile(typ gl.GLenum, stage, source string) (gl.Shader, error) {
+	shader := gl.CreateShader(typ)
+	shader.Source(source)
+	shader.Compile()
+	if err := glCheck("compiling " + stage + " shader " + s.Name); err != nil {
+		shader.Delete()
+		return 0, err
+	}
+	if shader.Get(gl.COMPILE_STATUS) == 0 {
+		log := shader.GetInfoLog()
+		shader.Delete()
+		return 0, &ShaderError{s.Name, stage, log}
+	}
+	return shader, nil
+}
+
+// ReloadIfChanged reloads the program if any of its files, including
+// those it includes, has changed since it was last built, and reports
+// whether it did. The program is only rebuilt once for each change, even
+// if the rebuild fails.
+func (s *ShaderProgram) ReloadIfChanged() (bool, error) {
+	if !s.stamps.changed(s.fsys) {
+		return false, nil
+	}
+	if err := s.Reload(); err != nil {
+		files := make([]string, 0, len(s.stamps))
+		for f := range s.stamps {
+			files = append(files, f)
+		}
+		s.stamps = stampFiles(s.fsys, files)
+		return false, err
+	}
+	return true, nil
+}
+
+// Use makes the program current.
+func (s *ShaderProgram) Use() {
+	s.Program.Use()
+}
+
+// Uniform returns the location of the named uniform, which is -1 if the
+// program does not use it.
+func (s *ShaderProgram) Uniform(name string) gl.UniformLocation {
+	loc, ok := s.uniforms[name]
+	if !ok {
+		loc = s.Program.GetUniformLocation(name)
+		s.uniforms[name] = loc
+	}
+	return loc
+}
+
+// Attrib returns the location of the named vertex attribute, which is -1
+// if the program does not use it.
+func (s *ShaderProgram) Attrib(name string) gl.AttribLocation {
+	loc, ok := s.attribs[name]
+	if !ok {
+		loc = s.Program.GetAttribLocation(name)
+		s.attribs[name] = loc
+	}
+	return loc
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ShaderSource is a shader after preprocessing. Lines from each file are
+// marked with #line directives whose source string number is the index of
+// the file in Files, so compile logs can be traced back to the file.
+type ShaderSource struct {
+	Text  string
+	Files []string // every file read, starting with the main file
+}
+
+// PreprocessShader reads the named shader from fsys, replacing each
+// `#include "file"` line with the contents of the file (relative to the
+// including file) and adding a #define for each of defines after any
+// #version directive, wherever it is.
+func PreprocessShader(fsys fs.FS, name string, defines map[string]string) (*ShaderSource, error) {
+	p := &preprocessor{fsys: fsys, index: make(map[string]int)}
+	var b strings.Builder
+	if err := p.include(&b, name, nil, defines); err != nil {
+		return nil, err
+	}
+	return &ShaderSource{b.String(), p.files}, nil
+}
+
+// preprocessor tracks the files read while preprocessing a shader.
+type preprocessor struct {
+	fsys  fs.FS
+	files []string
+	index map[string]int
+}
+
+// include writes the preprocessed contents of the named file to b. stack
+// holds the files which include it, to detect cycles; defines are written
+// at the start of the top-level file only.
+func (p *preprocessor) include(b *strings.Builder, name string, stack []string, defines map[string]string) error {
+	for _, s := range stack {
+		if s == name {
+			return fmt.Errorf("%s: include cycle through %s", stack[len(stack)-1], name)
+		}
+	}
+	data, err := fs.ReadFile(p.fsys, name)
+	if err != nil {
+		return err
+	}
+	n, ok := p.index[name]
+	if !ok {
+		n = len(p.files)
+		p.index[name] = n
+		p.files = append(p.files, name)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	start := 0
+	if stack == nil {
+		// #version must come before anything but comments and blank
+		// lines, including #line
+		for i, line := range lines {
+			if fields := strings.Fields(line); len(fields) > 0 && fields[0] == "#version" {
+				for _, l := range lines[:i+1] {
+					b.WriteString(l + "\n")
+				}
+				start = i + 1
+				break
+			}
+		}
+		names := make([]string, 0, len(defines))
+		for d := range defines {
+			names = append(names, d)
+		}
+		sort.Strings(names)
+		for _, d := range names {
+			fmt.Fprintf(b, "#define %s %s\n", d, defines[d])
+		}
+	}
+	fmt.Fprintf(b, "#line %d %d\n", start+1, n)
+
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "#include" {
+			b.WriteString(line + "\n")
+			continue
+		}
+		if len(fields) != 2 || len(fields[1]) < 2 || fields[1][0] != '"' || fields[1][len(fields[1])-1] != '"' {
+			return fmt.Errorf("%s:%d: malformed #include", name, i+1)
+		}
+		inc := path.Join(path.Dir(name), fields[1][1:len(fields[1])-1])
+		if err := p.include(b, inc, append(stack, name), nil); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, i+1, err)
+		}
+		fmt.Fprintf(b, "#line %d %d\n", i+2, n)
+	}
+	return nil
+}
+
+// ShaderError reports a shader which failed to compile or link, with the
+// log from the driver.
+type ShaderError struct {
+	Name  string // name of the shader program
+	Stage string // "vertex", "fragment" or "link"
+	Log   string
+}
+
+func (e *ShaderError) Error() string {
+	if e.Stage == "link" {
+		return fmt.Sprintf("linking shader %s: %s", e.Name, e.Log)
+	}
+	return fmt.Sprintf("compiling %s shader %s: %s", e.Stage, e.Name, e.Log)
+}
+
+// fileStamps records the modification times of a set of files, to notice
+// when they change.
+type fileStamps map[string]time.Time
+
+// stampFiles records the modification times of files in fsys. Files which
+// cannot be read are recorded with the zero time.
+func stampFiles(fsys fs.FS, files []string) fileStamps {
+	s := make(fileStamps, len(files))
+	for _, f := range files {
+		if info, err := fs.Stat(fsys, f); err == nil {
+			s[f] = info.ModTime()
+		} else {
+			s[f] = time.Time{}
+		}
+	}
+	return s
+}
+
+// changed reports whether any of the files has been modified, created or
+// removed since the stamps were recorded.
+func (s fileStamps) changed(fsys fs.FS) bool {
+	for f, t := range s {
+		info, err := fs.Stat(fsys, f)
+		if err != nil {
+			if !t.IsZero() {
+				return true
+			}
+			continue
+		}
+		if !info.ModTime().Equal(t) {
+			return true
+		}
+	}
+	return false
+}
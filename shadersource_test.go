@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestPreprocessShader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.fs":          {Data: []byte("#version 120\n#include \"lib/light.glsl\"\nvoid main(void) {\n\tgl_FragColor = light();\n}\n")},
+		"lib/light.glsl":   {Data: []byte("#include \"common.glsl\"\nvec4 light() { return vec4(SCALE); }\n")},
+		"lib/common.glsl":  {Data: []byte("const float one = 1.0;\n")},
+		"plain.vs":         {Data: []byte("void main(void) {}")},
+		"cycle.fs":         {Data: []byte("#include \"cycle2.fs\"\n")},
+		"cycle2.fs":        {Data: []byte("\n#include \"cycle.fs\"\n")},
+		"missing.fs":       {Data: []byte("void f();\n#include \"nothing.glsl\"\n")},
+		"malformed.fs":     {Data: []byte("#include <common.glsl>\n")},
+		"lib/noversion.fs": {Data: []byte("#include \"../plain.vs\"\n")},
+		"comment.fs":       {Data: []byte("// Lit surfaces\n\n  #version 120\nvoid main(void) {}\n")},
+	}
+
+	src, err := PreprocessShader(fsys, "main.fs", map[string]string{"SCALE": "0.5", "DEBUG": "1"})
+	if err != nil {
+		t.Fatal("PreprocessShader returned error: " + err.Error())
+	}
+	expected := strings.Join([]string{
+		"#version 120",
+		"#define DEBUG 1",
+		"#define SCALE 0.5",
+		"#line 2 0",
+		"#line 1 1",
+		"#line 1 2",
+		"const float one = 1.0;",
+		"#line 2 1",
+		"vec4 light() { return vec4(SCALE); }",
+		"#line 3 0",
+		"void main(void) {",
+		"\tgl_FragColor = light();",
+		"}",
+		"",
+	}, "\n")
+	if src.Text != expected {
+		t.Errorf("PreprocessShader returned\n%s\nexpected\n%s", src.Text, expected)
+	}
+	if strings.Join(src.Files, " ") != "main.fs lib/light.glsl lib/common.glsl" {
+		t.Error("PreprocessShader returned wrong files", src.Files)
+	}
+
+	src, err = PreprocessShader(fsys, "lib/noversion.fs", nil)
+	if err != nil {
+		t.Fatal("PreprocessShader returned error: " + err.Error())
+	}
+	if src.Text != "#line 1 0\n#line 1 1\nvoid main(void) {}\n#line 2 0\n" {
+		t.Errorf("PreprocessShader returned %q for include from parent directory", src.Text)
+	}
+
+	// #version after comments and blank lines still comes before #line
+	src, err = PreprocessShader(fsys, "comment.fs", map[string]string{"DEBUG": "1"})
+	if err != nil {
+		t.Fatal("PreprocessShader returned error: " + err.Error())
+	}
+	if src.Text != "// Lit surfaces\n\n  #version 120\n#define DEBUG 1\n#line 4 0\nvoid main(void) {}\n" {
+		t.Errorf("PreprocessShader returned %q for #version after comment", src.Text)
+	}
+
+	for _, c := range []struct{ name, msg string }{
+		{"cycle.fs", "cycle.fs:1: cycle2.fs:2: cycle2.fs: include cycle through cycle.fs"},
+		{"missing.fs", "missing.fs:2: "},
+		{"malformed.fs", "malformed.fs:1: malformed #include"},
+	} {
+		_, err := PreprocessShader(fsys, c.name, nil)
+		if err == nil || !strings.HasPrefix(err.Error(), c.msg) {
+			t.Errorf("PreprocessShader returned error %v for %s, expected %q", err, c.name, c.msg)
+		}
+	}
+	if _, err := PreprocessShader(fsys, "missing.fs", nil); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("PreprocessShader did not wrap error for missing include", err)
+	}
+}
+
+func TestFileStamps(t *testing.T) {
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"a.vs": {ModTime: now},
+		"b.fs": {ModTime: now},
+	}
+	s := stampFiles(fsys, []string{"a.vs", "b.fs", "c.glsl"})
+	if s.changed(fsys) {
+		t.Error("changed reported change for unchanged files")
+	}
+
+	fsys["b.fs"].ModTime = now.Add(time.Second)
+	if !s.changed(fsys) {
+		t.Error("changed did not report modified file")
+	}
+
+	s = stampFiles(fsys, []string{"a.vs", "b.fs", "c.glsl"})
+	fsys["c.glsl"] = &fstest.MapFile{ModTime: now}
+	if !s.changed(fsys) {
+		t.Error("changed did not report created file")
+	}
+
+	s = stampFiles(fsys, []string{"a.vs", "b.fs", "c.glsl"})
+	delete(fsys, "a.vs")
+	if !s.changed(fsys) {
+		t.Error("changed did not report removed file")
+	}
+}
+
+func TestShaderError(t *testing.T) {
+	err := &ShaderError{"basic", "fragment", "0(3) : error C0000: syntax error"}
+	if err.Error() != "compiling fragment shader basic: 0(3) : error C0000: syntax error" {
+		t.Error("ShaderError returned wrong message: " + err.Error())
+	}
+	err.Stage = "link"
+	if err.Error() != "linking shader basic: 0(3) : error C0000: syntax error" {
+		t.Error("ShaderError returned wrong message: " + err.Error())
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+// SplitAt checks whether removing the block at local voxel coordinates
+// (x, y, z), typically with SetBlock, disconnected the frame's voxels. If
+// so, every connected piece except the largest is moved into a new Frame
+// which keeps its world placement, and the new frames are returned.
+func (f *Frame) SplitAt(x, y, z int) []*Frame {
+	if !f.Block(x, y, z).IsEmpty() {
+		return nil
+	}
+
+	var seeds [][3]int
+	for _, n := range faceNormals {
+		v := [3]int{x + n[0], y + n[1], z + n[2]}
+		if !f.Block(v[0], v[1], v[2]).IsEmpty() {
+			seeds = append(seeds, v)
+		}
+	}
+	if len(seeds) < 2 {
+		return nil
+	}
+
+	return f.extract(f.detached(seeds))
+}
+
+// Split checks the connectivity of all the frame's voxels, moving every
+// connected piece except the largest into a new Frame which keeps its
+// world placement, and returns the new frames.
+func (f *Frame) Split() []*Frame {
+	var seeds [][3]int
+	for _, p := range f.chunkPositions() {
+		c := f.chunks[p]
+		for i := 0; i < ncx; i++ {
+			for j := 0; j < ncy; j++ {
+				for k := 0; k < ncz; k++ {
+					if !c[i][j][k].IsEmpty() {
+						seeds = append(seeds, [3]int{p.x*ncx + i, p.y*ncy + j, p.z*ncz + k})
+					}
+				}
+			}
+		}
+	}
+
+	comps := f.components(seeds)
+	if len(comps) < 2 {
+		return nil
+	}
+	largest := 0
+	for i, comp := range comps {
+		if len(comp) > len(comps[largest]) {
+			largest = i
+		}
+	}
+	return f.extract(append(comps[:largest], comps[largest+1:]...))
+}
+
+// components flood fills the non-empty voxels face-connected to each of
+// the seeds, returning each distinct connected component once.
+func (f *Frame) components(seeds [][3]int) (comps [][][3]int) {
+	visited := make(map[[3]int]bool)
+	for _, s := range seeds {
+		if visited[s] {
+			continue
+		}
+		visited[s] = true
+
+		comp := [][3]int{s}
+		for i := 0; i < len(comp); i++ {
+			v := comp[i]
+			for _, n := range faceNormals {
+				u := [3]int{v[0] + n[0], v[1] + n[1], v[2] + n[2]}
+				if !visited[u] && !f.Block(u[0], u[1], u[2]).IsEmpty() {
+					visited[u] = true
+					comp = append(comp, u)
+				}
+			}
+		}
+		comps = append(comps, comp)
+	}
+	return
+}
+
+// detached flood fills the non-empty voxels face-connected to each of the
+// seeds in lock-step, one voxel from each search in turn, merging searches
+// which meet. It stops once at most one search is still growing, so that
+// piece, which is at least as large as the others, is not walked in full,
+// and returns the other pieces. If every search finishes, the largest
+// piece is left out instead.
+func (f *Frame) detached(seeds [][3]int) (pieces [][][3]int) {
+	type search struct {
+		done, queue [][3]int
+	}
+	searches := make([]*search, len(seeds))
+	merged := make([]int, len(seeds)) // search each was merged into
+	owner := make(map[[3]int]int)
+	root := func(i int) int {
+		for merged[i] != i {
+			i = merged[i]
+		}
+		return i
+	}
+	for i, v := range seeds {
+		searches[i] = &search{queue: [][3]int{v}}
+		merged[i] = i
+		owner[v] = i
+	}
+
+	for growing := len(seeds); growing > 1; {
+		growing = 0
+		for i, s := range searches {
+			if merged[i] != i || len(s.queue) == 0 {
+				continue
+			}
+			v := s.queue[0]
+			s.queue = s.queue[1:]
+			s.done = append(s.done, v)
+			for _, n := range faceNormals {
+				u := [3]int{v[0] + n[0], v[1] + n[1], v[2] + n[2]}
+				j, ok := owner[u]
+				if !ok {
+					if !f.Block(u[0], u[1], u[2]).IsEmpty() {
+						owner[u] = i
+						s.queue = append(s.queue, u)
+					}
+				} else if j = root(j); j != i {
+					s.done = append(s.done, searches[j].done...)
+					s.queue = append(s.queue, searches[j].queue...)
+					merged[j] = i
+				}
+			}
+		}
+		for i, s := range searches {
+			if merged[i] == i && len(s.queue) > 0 {
+				growing++
+			}
+		}
+	}
+
+	largest := -1
+	for i, s := range searches {
+		if merged[i] != i {
+			continue
+		}
+		if len(s.queue) > 0 || largest < 0 || len(searches[largest].queue) == 0 && len(s.done) > len(searches[largest].done) {
+			largest = i
+		}
+	}
+	for i, s := range searches {
+		if merged[i] == i && i != largest {
+			pieces = append(pieces, s.done)
+		}
+	}
+	return
+}
+
+// extract moves each of the pieces into a new Frame with the same
+// transform, parent and block registry as f. Blocks moved out of f are not
+// mined.
+func (f *Frame) extract(pieces [][][3]int) (frames []*Frame) {
+	for _, piece := range pieces {
+		g := NewFrame()
+		*g.Transform = *f.Transform
+		g.Registry = f.Registry
+		if f.parent != nil {
+			g.parent = f.parent
+			f.parent.children = append(f.parent.children, g)
+		}
+
+		for _, v := range piece {
+			g.SetBlock(v[0], v[1], v[2], f.Block(v[0], v[1], v[2]))
+			f.setBlock(v[0], v[1], v[2], Block{})
+		}
+		frames = append(frames, g)
+	}
+	return
+}
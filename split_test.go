@@ -0,0 +1,138 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitAt(t *testing.T) {
+	f := NewFrame()
+	f.Transform.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{0, 1, 0}))
+	f.Transform.SetTranslation(Vec3{3, 4, 5})
+
+	// A bar crossing the chunk boundary at zero, cut in two at x = -3
+	for x := -10; x < 10; x++ {
+		f.SetBlock(x, 0, 0, Block{uint(x + 11), 0})
+	}
+	f.SetBlock(5, 1, 0, Block{50, 0})
+
+	f.SetBlock(5, 1, 0, Block{})
+	if frames := f.SplitAt(5, 1, 0); len(frames) != 0 {
+		t.Error("SplitAt split frame after removing a leaf block")
+	}
+
+	f.SetBlock(-3, 0, 0, Block{})
+	frames := f.SplitAt(-3, 0, 0)
+	if len(frames) != 1 {
+		t.Fatalf("SplitAt returned %d frames, expected 1", len(frames))
+	}
+	g := frames[0]
+
+	// The larger piece stays in f
+	for x := -10; x < 10; x++ {
+		switch {
+		case x < -3:
+			if !f.Block(x, 0, 0).IsEmpty() {
+				t.Errorf("SplitAt left block at %d in original frame", x)
+			}
+			if g.Block(x, 0, 0).Id != uint(x+11) {
+				t.Errorf("SplitAt did not move block at %d to new frame", x)
+			}
+		case x > -3:
+			if f.Block(x, 0, 0).Id != uint(x+11) {
+				t.Errorf("SplitAt removed block at %d from original frame", x)
+			}
+			if !g.Block(x, 0, 0).IsEmpty() {
+				t.Errorf("SplitAt copied block at %d to new frame", x)
+			}
+		}
+	}
+	if f.Mass() != 12 || g.Mass() != 7 {
+		t.Errorf("SplitAt left masses %v and %v, expected 12 and 7", f.Mass(), g.Mass())
+	}
+
+	// Both pieces keep their world placement
+	checkPoints(t, "SplitAt new frame", g.World(), f.World())
+
+	if frames := f.SplitAt(0, 0, 0); frames != nil {
+		t.Error("SplitAt split frame at non-empty block")
+	}
+}
+
+func TestSplitAtSolid(t *testing.T) {
+	// Removing a block inside a solid chunk leaves it connected around
+	// the hole
+	f := solidFrame(1, Block{1, 0})
+	f.SetBlock(5, 5, 5, Block{})
+	if frames := f.SplitAt(5, 5, 5); frames != nil {
+		t.Error("SplitAt split frame around a hole")
+	}
+
+	// A stub cut off the chunk moves, leaving the chunk in place
+	f.SetBlock(ncx, 0, 0, Block{1, 0})
+	f.SetBlock(ncx+1, 0, 0, Block{1, 0})
+	f.SetBlock(ncx+1, 1, 0, Block{1, 0})
+	f.SetBlock(ncx, 0, 0, Block{})
+	frames := f.SplitAt(ncx, 0, 0)
+	if len(frames) != 1 || frames[0].Mass() != 2 {
+		t.Fatal("SplitAt did not move stub cut off a solid chunk")
+	}
+	if f.Mass() != ncx*ncy*ncz-1 {
+		t.Errorf("SplitAt left mass %v in original frame, expected %v", f.Mass(), ncx*ncy*ncz-1)
+	}
+}
+
+func TestSplitChild(t *testing.T) {
+	parent := NewFrame()
+	parent.Transform.SetTranslation(Vec3{1, 2, 3})
+	f := NewFrame()
+	f.SetParent(parent)
+	f.Transform.SetScale(2)
+
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	f.SetBlock(0, 0, 1, Block{1, 0})
+	f.SetBlock(0, 0, 2, Block{1, 0})
+	f.SetBlock(0, 0, 1, Block{})
+	frames := f.SplitAt(0, 0, 1)
+	if len(frames) != 1 {
+		t.Fatalf("SplitAt returned %d frames, expected 1", len(frames))
+	}
+	if frames[0].Parent() != parent || len(parent.Children()) != 2 {
+		t.Error("SplitAt did not attach new frame to parent")
+	}
+	checkPoints(t, "SplitAt new child frame", frames[0].World(), f.World())
+}
+
+func TestSplit(t *testing.T) {
+	f := NewFrame()
+	for i := 0; i < 4; i++ {
+		f.SetBlock(100, i, 0, Block{1, 0})
+	}
+	f.SetBlock(-20, 0, 0, Block{1, 0})
+	f.SetBlock(-20, -1, 0, Block{1, 0})
+	f.SetBlock(0, 0, 0, Block{1, 0})
+	// Diagonal neighbours are not connected
+	f.SetBlock(1, 1, 0, Block{1, 0})
+
+	frames := f.Split()
+	if len(frames) != 3 {
+		t.Fatalf("Split returned %d frames, expected 3", len(frames))
+	}
+	if f.Mass() != 4 {
+		t.Errorf("Split left mass %v in original frame, expected 4", f.Mass())
+	}
+	total := 0.0
+	for _, g := range frames {
+		total += g.Mass()
+		if frames := g.Split(); frames != nil {
+			t.Error("Split returned frame which splits further")
+		}
+	}
+	if total != 4 {
+		t.Errorf("Split moved mass %v to new frames, expected 4", total)
+	}
+
+	if frames := f.Split(); frames != nil {
+		t.Error("Split split connected frame")
+	}
+}
@@ -21,7 +21,7 @@ func TestNewSQT(t *testing.T) {
 
 func TestRotate(t *testing.T) {
 	s := NewSQT()
-	s.SetRotation(math.Pi/2, 1, 0, 0)
+	s.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{1, 0, 0}))
 	checkTransform(t, "SetRotation", "pi/2 about x", s,
 		[16]float32{
 			1, 0, 0, 0,
@@ -37,7 +37,7 @@ func TestRotate(t *testing.T) {
 		0, -1, 0,
 	)
 
-	s.SetRotation(math.Pi, 0, 1, 0)
+	s.SetRotation(QuatAxisAngle(math.Pi, Vec3{0, 1, 0}))
 	checkTransform(t, "SetRotation", "pi about y", s,
 		[16]float32{
 			-1, 0, 0, 0,
@@ -53,7 +53,7 @@ func TestRotate(t *testing.T) {
 		0, 0, -1,
 	)
 
-	s.SetRotation(3*math.Pi/2, 0, 0, -1)
+	s.SetRotation(QuatAxisAngle(3*math.Pi/2, Vec3{0, 0, -1}))
 	checkTransform(t, "SetRotation", "3pi/2 about -z", s,
 		[16]float32{
 			0, -1, 0, 0,
@@ -69,7 +69,7 @@ func TestRotate(t *testing.T) {
 		0, 0, 1,
 	)
 
-	s.Rotate(math.Pi/2, -1, 0, 0)
+	s.Rotate(QuatAxisAngle(math.Pi/2, Vec3{-1, 0, 0}))
 	checkTransform(t, "Rotate", "3pi/2 about -z then pi/2 about x", s,
 		[16]float32{
 			0, -1, 0, 0,
@@ -88,7 +88,7 @@ func TestRotate(t *testing.T) {
 
 func TestTranslate(t *testing.T) {
 	s := NewSQT()
-	s.SetTranslation(5, 0, 0)
+	s.SetTranslation(Vec3{5, 0, 0})
 	checkTransform(t, "SetTranslation", "(+5, 0, 0)", s,
 		[16]float32{
 			1, 0, 0, 5,
@@ -104,7 +104,7 @@ func TestTranslate(t *testing.T) {
 		0, 0, 1,
 	)
 
-	s.SetTranslation(0, 1, -1)
+	s.SetTranslation(Vec3{0, 1, -1})
 	checkTransform(t, "SetTranslation", "(0, +1, -1)", s,
 		[16]float32{
 			1, 0, 0, 0,
@@ -120,7 +120,7 @@ func TestTranslate(t *testing.T) {
 		0, 0, 1,
 	)
 
-	s.Translate(5, -1, 6)
+	s.Translate(Vec3{5, -1, 6})
 	checkTransform(t, "Translate", "(0, +1, -1) then (+5, -1, +6)", s,
 		[16]float32{
 			1, 0, 0, 5,
@@ -190,8 +190,8 @@ func TestScale(t *testing.T) {
 
 func TestOrder(t *testing.T) {
 	s := NewSQT()
-	s.SetRotation(math.Pi/2, 1, 0, 0)
-	s.SetTranslation(2, 1, -3)
+	s.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{1, 0, 0}))
+	s.SetTranslation(Vec3{2, 1, -3})
 	s.SetScale(-1)
 	checkTransform(t, "SQT", "rotation by pi/2 about x, translation by (+2, +1, -3) and scale by *-1", s,
 		[16]float32{
@@ -208,8 +208,8 @@ func TestOrder(t *testing.T) {
 		0, 1, 0,
 	)
 
-	s.SetRotation(3*math.Pi/2, 0, 1, 0)
-	s.SetTranslation(0, 4, 0)
+	s.SetRotation(QuatAxisAngle(3*math.Pi/2, Vec3{0, 1, 0}))
+	s.SetTranslation(Vec3{0, 4, 0})
 	s.SetScale(5)
 	checkTransform(t, "SQT", "rotation by 3pi/2 about y, translation by (0, +4, +0) and scale by *5", s,
 		[16]float32{
@@ -229,66 +229,66 @@ func TestOrder(t *testing.T) {
 
 func TestCompose(t *testing.T) {
 	s1 := NewSQT()
-	s1.SetRotation(math.Pi/2, 1, 0, 0)
-	s1.SetTranslation(2, 1, -3)
+	s1.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{1, 0, 0}))
+	s1.SetTranslation(Vec3{2, 1, -3})
 	s1.SetScale(0.5)
 
 	s2 := NewSQT()
-	s2.SetRotation(3*math.Pi/2, 0, 1, 0)
-	s2.SetTranslation(0, 4, 0)
+	s2.SetRotation(QuatAxisAngle(3*math.Pi/2, Vec3{0, 1, 0}))
+	s2.SetTranslation(Vec3{0, 4, 0})
 	s2.SetScale(-3)
 
 	s3 := s2.Compose(s1)
 
-	x1x, x1y, x1z := s1.TransformAbs(1, 0, 0)
-	y1x, y1y, y1z := s1.TransformAbs(0, 1, 0)
-	z1x, z1y, z1z := s1.TransformAbs(0, 0, 1)
+	x1 := s1.TransformAbs(Vec3{1, 0, 0})
+	y1 := s1.TransformAbs(Vec3{0, 1, 0})
+	z1 := s1.TransformAbs(Vec3{0, 0, 1})
 
-	x2x, x2y, x2z := s2.TransformAbs(x1x, x1y, x1z)
-	y2x, y2y, y2z := s2.TransformAbs(y1x, y1y, y1z)
-	z2x, z2y, z2z := s2.TransformAbs(z1x, z1y, z1z)
+	x2 := s2.TransformAbs(x1)
+	y2 := s2.TransformAbs(y1)
+	z2 := s2.TransformAbs(z1)
 
-	x3x, x3y, x3z := s3.TransformAbs(1, 0, 0)
-	y3x, y3y, y3z := s3.TransformAbs(0, 1, 0)
-	z3x, z3y, z3z := s3.TransformAbs(0, 0, 1)
+	x3 := s3.TransformAbs(Vec3{1, 0, 0})
+	y3 := s3.TransformAbs(Vec3{0, 1, 0})
+	z3 := s3.TransformAbs(Vec3{0, 0, 1})
 
-	if math.Abs(x3x-x2x) > 1e-14 || math.Abs(x3y-x2y) > 1e-14 || math.Abs(x3z-x2z) > 1e-14 {
+	if math.Abs(x3.X-x2.X) > 1e-14 || math.Abs(x3.Y-x2.Y) > 1e-14 || math.Abs(x3.Z-x2.Z) > 1e-14 {
 		t.Errorf("%s", "Compose did not absolute transform x the same as sequential transformation")
 	}
-	if math.Abs(y3x-y2x) > 1e-14 || math.Abs(y3y-y2y) > 1e-14 || math.Abs(y3z-y2z) > 1e-14 {
+	if math.Abs(y3.X-y2.X) > 1e-14 || math.Abs(y3.Y-y2.Y) > 1e-14 || math.Abs(y3.Z-y2.Z) > 1e-14 {
 		t.Errorf("%s", "Compose did not absolute transform y the same as sequential transformation")
 	}
-	if math.Abs(z3x-z2x) > 1e-14 || math.Abs(z3y-z2y) > 1e-14 || math.Abs(z3z-z2z) > 1e-14 {
+	if math.Abs(z3.X-z2.X) > 1e-14 || math.Abs(z3.Y-z2.Y) > 1e-14 || math.Abs(z3.Z-z2.Z) > 1e-14 {
 		t.Errorf("%s", "Compose did not absolute transform z the same as sequential transformation")
 	}
 
-	x1x, x1y, x1z = s1.TransformRel(1, 0, 0)
-	y1x, y1y, y1z = s1.TransformRel(0, 1, 0)
-	z1x, z1y, z1z = s1.TransformRel(0, 0, 1)
+	x1 = s1.TransformRel(Vec3{1, 0, 0})
+	y1 = s1.TransformRel(Vec3{0, 1, 0})
+	z1 = s1.TransformRel(Vec3{0, 0, 1})
 
-	x2x, x2y, x2z = s2.TransformRel(x1x, x1y, x1z)
-	y2x, y2y, y2z = s2.TransformRel(y1x, y1y, y1z)
-	z2x, z2y, z2z = s2.TransformRel(z1x, z1y, z1z)
+	x2 = s2.TransformRel(x1)
+	y2 = s2.TransformRel(y1)
+	z2 = s2.TransformRel(z1)
 
-	x3x, x3y, x3z = s3.TransformRel(1, 0, 0)
-	y3x, y3y, y3z = s3.TransformRel(0, 1, 0)
-	z3x, z3y, z3z = s3.TransformRel(0, 0, 1)
+	x3 = s3.TransformRel(Vec3{1, 0, 0})
+	y3 = s3.TransformRel(Vec3{0, 1, 0})
+	z3 = s3.TransformRel(Vec3{0, 0, 1})
 
-	if math.Abs(x3x-x2x) > 1e-14 || math.Abs(x3y-x2y) > 1e-14 || math.Abs(x3z-x2z) > 1e-14 {
+	if math.Abs(x3.X-x2.X) > 1e-14 || math.Abs(x3.Y-x2.Y) > 1e-14 || math.Abs(x3.Z-x2.Z) > 1e-14 {
 		t.Errorf("%s", "Compose did not relative transform x the same as sequential transformation")
 	}
-	if math.Abs(y3x-y2x) > 1e-14 || math.Abs(y3y-y2y) > 1e-14 || math.Abs(y3z-y2z) > 1e-14 {
+	if math.Abs(y3.X-y2.X) > 1e-14 || math.Abs(y3.Y-y2.Y) > 1e-14 || math.Abs(y3.Z-y2.Z) > 1e-14 {
 		t.Errorf("%s", "Compose did not relative transform y the same as sequential transformation")
 	}
-	if math.Abs(z3x-z2x) > 1e-14 || math.Abs(z3y-z2y) > 1e-14 || math.Abs(z3z-z2z) > 1e-14 {
+	if math.Abs(z3.X-z2.X) > 1e-14 || math.Abs(z3.Y-z2.Y) > 1e-14 || math.Abs(z3.Z-z2.Z) > 1e-14 {
 		t.Errorf("%s", "Compose did not relative transform z the same as sequential transformation")
 	}
 }
 
 func TestInverse(t *testing.T) {
 	s := NewSQT()
-	s.SetRotation(math.Pi/2, 1, 0, 0)
-	s.SetTranslation(2, 1, -3)
+	s.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{1, 0, 0}))
+	s.SetTranslation(Vec3{2, 1, -3})
 	s.SetScale(-1)
 	i := s.Compose(s.Inverse())
 	checkTransform(t, "Inverse", "rotation by pi/2 about x, translation by (+2, +1, -3) and scale by *-1", i,
@@ -306,8 +306,8 @@ func TestInverse(t *testing.T) {
 		0, 0, 1,
 	)
 
-	s.SetRotation(3*math.Pi/2, 0, 1, 0)
-	s.SetTranslation(0, 4, 0)
+	s.SetRotation(QuatAxisAngle(3*math.Pi/2, Vec3{0, 1, 0}))
+	s.SetTranslation(Vec3{0, 4, 0})
 	s.SetScale(5)
 	i = s.Compose(s.Inverse())
 	checkTransform(t, "Inverse", "rotation by 3pi/2 about y, translation by (0, +4, +0) and scale by *5", i,
@@ -337,6 +337,8 @@ func checkTransform(
 	zpxr, zpyr, zpzr float64,
 ) {
 	m := s.Matrix()
+	var M Matrix4
+	M.LoadArray(m)
 
 	x := [4]float32{1, 0, 0, 1}
 	y := [4]float32{0, 1, 0, 1}
@@ -346,51 +348,51 @@ func checkTransform(
 		t.Error(operation + " did not return correct transformation for " + desc)
 	}
 
-	mx := matmul(m, x)
+	mx := M.Transform(x)
 	xp := [4]float32{float32(xpx), float32(xpy), float32(xpz), 1}
 	if diff(xp[:], mx[:]) > 1e-16 {
 		t.Error(operation + " did not return expected transformation for x for " + desc)
 	}
 
-	my := matmul(m, y)
+	my := M.Transform(y)
 	yp := [4]float32{float32(ypx), float32(ypy), float32(ypz), 1}
 	if diff(yp[:], my[:]) > 1e-16 {
 		t.Error(operation + " did not return expected transformation for y for " + desc)
 	}
 
-	mz := matmul(m, z)
+	mz := M.Transform(z)
 	zp := [4]float32{float32(zpx), float32(zpy), float32(zpz), 1}
 	if diff(zp[:], mz[:]) > 1e-16 {
 		t.Error(operation + " did not return expected transformation for z for " + desc)
 	}
 
-	xppx, xppy, xppz := s.TransformAbs(1, 0, 0)
-	if math.Abs(xppx-xpx) > 1e-15 || math.Abs(xppy-xpy) > 1e-15 || math.Abs(xppz-xpz) > 1e-15 {
+	xpp := s.TransformAbs(Vec3{1, 0, 0})
+	if math.Abs(xpp.X-xpx) > 1e-15 || math.Abs(xpp.Y-xpy) > 1e-15 || math.Abs(xpp.Z-xpz) > 1e-15 {
 		t.Error(operation + " did not transform x as expected for " + desc)
 	}
 
-	yppx, yppy, yppz := s.TransformAbs(0, 1, 0)
-	if math.Abs(yppx-ypx) > 1e-15 || math.Abs(yppy-ypy) > 1e-15 || math.Abs(yppz-ypz) > 1e-15 {
+	ypp := s.TransformAbs(Vec3{0, 1, 0})
+	if math.Abs(ypp.X-ypx) > 1e-15 || math.Abs(ypp.Y-ypy) > 1e-15 || math.Abs(ypp.Z-ypz) > 1e-15 {
 		t.Error(operation + " did not transform y as expected for " + desc)
 	}
 
-	zppx, zppy, zppz := s.TransformAbs(0, 0, 1)
-	if math.Abs(zppx-zpx) > 1e-15 || math.Abs(zppy-zpy) > 1e-15 || math.Abs(zppz-zpz) > 1e-15 {
+	zpp := s.TransformAbs(Vec3{0, 0, 1})
+	if math.Abs(zpp.X-zpx) > 1e-15 || math.Abs(zpp.Y-zpy) > 1e-15 || math.Abs(zpp.Z-zpz) > 1e-15 {
 		t.Error(operation + " did not transform z as expected for " + desc)
 	}
 
-	xppx, xppy, xppz = s.TransformRel(1, 0, 0)
-	if math.Abs(xppx-xpxr) > 1e-15 || math.Abs(xppy-xpyr) > 1e-15 || math.Abs(xppz-xpzr) > 1e-15 {
+	xpp = s.TransformRel(Vec3{1, 0, 0})
+	if math.Abs(xpp.X-xpxr) > 1e-15 || math.Abs(xpp.Y-xpyr) > 1e-15 || math.Abs(xpp.Z-xpzr) > 1e-15 {
 		t.Error(operation + " did not transform x as expected for " + desc)
 	}
 
-	yppx, yppy, yppz = s.TransformRel(0, 1, 0)
-	if math.Abs(yppx-ypxr) > 1e-15 || math.Abs(yppy-ypyr) > 1e-15 || math.Abs(yppz-ypzr) > 1e-15 {
+	ypp = s.TransformRel(Vec3{0, 1, 0})
+	if math.Abs(ypp.X-ypxr) > 1e-15 || math.Abs(ypp.Y-ypyr) > 1e-15 || math.Abs(ypp.Z-ypzr) > 1e-15 {
 		t.Error(operation + " did not transform y as expected for " + desc)
 	}
 
-	zppx, zppy, zppz = s.TransformRel(0, 0, 1)
-	if math.Abs(zppx-zpxr) > 1e-15 || math.Abs(zppy-zpyr) > 1e-15 || math.Abs(zppz-zpzr) > 1e-15 {
+	zpp = s.TransformRel(Vec3{0, 0, 1})
+	if math.Abs(zpp.X-zpxr) > 1e-15 || math.Abs(zpp.Y-zpyr) > 1e-15 || math.Abs(zpp.Z-zpzr) > 1e-15 {
 		t.Error(operation + " did not transform z as expected for " + desc)
 	}
 }
@@ -403,11 +405,19 @@ func diff(a, b []float32) float32 {
 	return diff
 }
 
-func matmul(M [16]float32, v [4]float32) [4]float32 {
-	return [4]float32{
-		M[0]*v[0] + M[1]*v[1] + M[2]*v[2] + M[3]*v[3],
-		M[4]*v[0] + M[5]*v[1] + M[6]*v[2] + M[7]*v[3],
-		M[8]*v[0] + M[9]*v[1] + M[10]*v[2] + M[11]*v[3],
-		M[12]*v[0] + M[13]*v[1] + M[14]*v[2] + M[15]*v[3],
-	}
+func TestLerp(t *testing.T) {
+	a := NewSQT()
+	b := NewSQT()
+	b.SetScale(4)
+	b.SetRotation(QuatAxisAngle(math.Pi/2, Vec3{0, 0, 1}))
+	b.SetTranslation(Vec3{2, 4, -6})
+
+	checkPoints(t, "Lerp at 0", a.Lerp(b, 0), a)
+	checkPoints(t, "Lerp at 1", a.Lerp(b, 1), b)
+
+	expected := NewSQT()
+	expected.SetScale(2)
+	expected.SetRotation(QuatAxisAngle(math.Pi/4, Vec3{0, 0, 1}))
+	expected.SetTranslation(Vec3{1, 2, -3})
+	checkPoints(t, "Lerp at 0.5", a.Lerp(b, 0.5), expected)
 }
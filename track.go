@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Interpolation selects how a Track blends between keyframes.
+type Interpolation int
+
+const (
+	Linear Interpolation = iota // straight lines between keyframes
+	Cubic                       // Catmull-Rom splines through the keyframes
+)
+
+// Keyframe is a pose of an animation Track at a given time.
+type Keyframe struct {
+	Time      float64
+	Transform SQT
+}
+
+// Track is a keyframed animation of an SQT transformation.
+type Track struct {
+	Interpolation Interpolation
+	Loop          bool // times wrap around from the last keyframe to the start
+	keys          []Keyframe
+}
+
+// NewTrack creates a track with no keyframes.
+func NewTrack(interpolation Interpolation) *Track {
+	return &Track{Interpolation: interpolation}
+}
+
+// AddKey adds a keyframe with the pose s at time t, replacing any keyframe
+// already at that time.
+func (tr *Track) AddKey(t float64, s *SQT) {
+	i := sort.Search(len(tr.keys), func(i int) bool { return tr.keys[i].Time >= t })
+	if i < len(tr.keys) && tr.keys[i].Time == t {
+		tr.keys[i].Transform = *s
+		return
+	}
+	tr.keys = append(tr.keys, Keyframe{})
+	copy(tr.keys[i+1:], tr.keys[i:])
+	tr.keys[i] = Keyframe{t, *s}
+}
+
+// Keys returns the keyframes of the track in time order.
+func (tr *Track) Keys() []Keyframe {
+	return append([]Keyframe(nil), tr.keys...)
+}
+
+// Duration returns the time from the first keyframe to the last.
+func (tr *Track) Duration() float64 {
+	if len(tr.keys) == 0 {
+		return 0
+	}
+	return tr.keys[len(tr.keys)-1].Time - tr.keys[0].Time
+}
+
+// Sample returns the pose of the track at time t. Times before the first
+// keyframe or after the last are clamped, unless the track loops. A track
+// with no keyframes returns the identity transformation.
+func (tr *Track) Sample(t float64) *SQT {
+	n := len(tr.keys)
+	if n == 0 {
+		return NewSQT()
+	}
+	if d := tr.Duration(); tr.Loop && d > 0 {
+		start := tr.keys[0].Time
+		t = math.Mod(t-start, d)
+		if t < 0 {
+			t += d
+		}
+		t += start
+	}
+	if t <= tr.keys[0].Time {
+		s := tr.keys[0].Transform
+		return &s
+	}
+	if t >= tr.keys[n-1].Time {
+		s := tr.keys[n-1].Transform
+		return &s
+	}
+
+	i := sort.Search(n, func(i int) bool { return tr.keys[i].Time > t }) - 1
+	a, b := &tr.keys[i], &tr.keys[i+1]
+	u := (t - a.Time) / (b.Time - a.Time)
+
+	if tr.Interpolation == Linear {
+		return a.Transform.Lerp(&b.Transform, u)
+	}
+
+	// Cubic splines through the neighbouring keyframes, with the end
+	// keyframes repeated where there are no neighbours
+	p0, p3 := a, b
+	if i > 0 {
+		p0 = &tr.keys[i-1]
+	}
+	if i+2 < n {
+		p3 = &tr.keys[i+2]
+	}
+	w := catmullRom(u)
+
+	s := NewSQT()
+	s.t = p0.Transform.t.Mul(w[0]).
+		Add(a.Transform.t.Mul(w[1])).
+		Add(b.Transform.t.Mul(w[2])).
+		Add(p3.Transform.t.Mul(w[3]))
+	l := w[0]*math.Log(math.Abs(p0.Transform.scale)) +
+		w[1]*math.Log(math.Abs(a.Transform.scale)) +
+		w[2]*math.Log(math.Abs(b.Transform.scale)) +
+		w[3]*math.Log(math.Abs(p3.Transform.scale))
+	s.scale = math.Copysign(math.Exp(l), a.Transform.scale)
+
+	qa, qb := a.Transform.q, b.Transform.q
+	ca := squadControl(p0.Transform.q, qa, qb)
+	cb := squadControl(qa, qb, p3.Transform.q)
+	s.q = Slerp(Slerp(qa, qb, u), Slerp(ca, cb, u), 2*u*(1-u))
+	return s
+}
+
+// catmullRom returns the weights of the four control points of a uniform
+// Catmull-Rom spline segment at u, which runs from the second point to the
+// third.
+func catmullRom(u float64) [4]float64 {
+	u2, u3 := u*u, u*u*u
+	return [4]float64{
+		(-u3 + 2*u2 - u) / 2,
+		(3*u3 - 5*u2 + 2) / 2,
+		(-3*u3 + 4*u2 + u) / 2,
+		(u3 - u2) / 2,
+	}
+}
+
+// squadControl returns the inner control point at the rotation q for
+// spherical quadrangle interpolation between prev, q and next, giving a
+// smooth curve through q.
+func squadControl(prev, q, next Quat) Quat {
+	if q.Dot(prev) < 0 {
+		prev = Quat{-prev.X, -prev.Y, -prev.Z, -prev.W}
+	}
+	if q.Dot(next) < 0 {
+		next = Quat{-next.X, -next.Y, -next.Z, -next.W}
+	}
+	inv := q.Conjugate()
+	l := qlog(inv.Mul(next)).Add(qlog(inv.Mul(prev)))
+	return q.Mul(qexp(l.Mul(-0.25)))
+}
+
+// qlog returns the logarithm of the unit quaternion q, which is the
+// vector part of a pure quaternion: the axis of rotation scaled by half
+// the angle.
+func qlog(q Quat) Vec3 {
+	v := Vec3{q.X, q.Y, q.Z}
+	sin := v.Length()
+	if sin == 0 {
+		return Vec3{}
+	}
+	return v.Mul(math.Atan2(sin, q.W) / sin)
+}
+
+// qexp returns the exponential of the pure quaternion with vector part v,
+// inverting qlog.
+func qexp(v Vec3) Quat {
+	theta := v.Length()
+	if theta == 0 {
+		return QuatIdentity
+	}
+	a := v.Mul(math.Sin(theta) / theta)
+	return Quat{a.X, a.Y, a.Z, math.Cos(theta)}
+}
+
+// Animator drives the Transform of a Frame from a Track as time passes.
+type Animator struct {
+	Track *Track
+	Frame *Frame
+	Time  float64
+}
+
+// Tick advances the animation by dt seconds and sets the frame's
+// Transform to the pose of the track at the new time.
+func (a *Animator) Tick(dt float64) {
+	a.Time += dt
+	*a.Frame.Transform = *a.Track.Sample(a.Time)
+}
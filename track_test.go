@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrackLinear(t *testing.T) {
+	tr := NewTrack(Linear)
+	checkPoints(t, "Sample of empty track", tr.Sample(1), NewSQT())
+
+	a := NewSQT()
+	b := NewSQT()
+	b.SetTranslation(Vec3{10, 0, 0})
+	c := NewSQT()
+	c.SetTranslation(Vec3{10, 10, 0})
+	tr.AddKey(2, c)
+	tr.AddKey(0, a)
+	tr.AddKey(1, b)
+
+	if len(tr.Keys()) != 3 || tr.Duration() != 2 {
+		t.Fatal("AddKey did not add keyframes", tr.Keys())
+	}
+	for i, k := range tr.Keys() {
+		if k.Time != float64(i) {
+			t.Error("Keys did not return keyframes in time order", tr.Keys())
+		}
+	}
+
+	checkPoints(t, "Sample at keyframe", tr.Sample(1), b)
+	checkPoints(t, "Sample before start", tr.Sample(-1), a)
+	checkPoints(t, "Sample after end", tr.Sample(5), c)
+
+	expected := NewSQT()
+	expected.SetTranslation(Vec3{10, 2.5, 0})
+	checkPoints(t, "Sample between keyframes", tr.Sample(1.25), expected)
+
+	tr.Loop = true
+	checkPoints(t, "Sample of looping track", tr.Sample(5.25), expected)
+	expected.SetTranslation(Vec3{2.5, 0, 0})
+	checkPoints(t, "Sample of looping track before start", tr.Sample(-1.75), expected)
+
+	// Replacing a keyframe
+	tr.AddKey(1, a)
+	if len(tr.Keys()) != 3 {
+		t.Error("AddKey at existing time added keyframe")
+	}
+	checkPoints(t, "Sample at replaced keyframe", tr.Sample(1), a)
+
+	// Looping tracks which start later repeat from their first keyframe
+	late := NewTrack(Linear)
+	late.Loop = true
+	late.AddKey(10, a)
+	late.AddKey(12, b)
+	if late.Duration() != 2 {
+		t.Errorf("Duration returned %v for track from 10 to 12, expected 2", late.Duration())
+	}
+	expected.SetTranslation(Vec3{2.5, 0, 0})
+	for _, at := range []float64{10.5, 12.5, 16.5, 4.5, -1.5} {
+		checkPoints(t, "Sample of looping track starting late", late.Sample(at), expected)
+	}
+}
+
+func TestTrackCubic(t *testing.T) {
+	tr := NewTrack(Cubic)
+	for i := 0; i < 4; i++ {
+		s := NewSQT()
+		s.SetTranslation(Vec3{float64(i), float64(i * i), 0})
+		s.SetScale(math.Pow(2, float64(i)))
+		s.SetRotation(QuatAxisAngle(float64(i)/2, Vec3{0, 1, 0}))
+		tr.AddKey(float64(i), s)
+	}
+
+	for _, k := range tr.Keys() {
+		checkPoints(t, "Cubic sample at keyframe", tr.Sample(k.Time), &k.Transform)
+	}
+
+	// Collinear, evenly spaced keyframes give a straight line at constant speed
+	s := tr.Sample(1.5)
+	if math.Abs(s.scale-math.Pow(2, 1.5)) > 1e-12 {
+		t.Errorf("Cubic sample returned scale %v, expected %v", s.scale, math.Pow(2, 1.5))
+	}
+	theta, axis := s.Rotation().AxisAngle()
+	if math.Abs(theta-0.75) > 1e-9 || axis.Sub(Vec3{0, 1, 0}).Length() > 1e-9 {
+		t.Errorf("Cubic sample returned rotation %v about %v, expected 0.75 about y", theta, axis)
+	}
+
+	// The spline passes through the keyframes of y = x*x smoothly, so
+	// sampling just either side of a keyframe gives a similar velocity
+	const h = 1e-6
+	before := tr.Sample(2).t.Sub(tr.Sample(2 - h).t).Mul(1 / h)
+	after := tr.Sample(2 + h).t.Sub(tr.Sample(2).t).Mul(1 / h)
+	if before.Sub(after).Length() > 1e-3 {
+		t.Errorf("Cubic track velocity jumped from %v to %v at keyframe", before, after)
+	}
+	linear := NewTrack(Linear)
+	for _, k := range tr.Keys() {
+		linear.AddKey(k.Time, &k.Transform)
+	}
+	if p := tr.Sample(1.5).t; math.Abs(p.Y-2.25) > 0.2 || p.Y == linear.Sample(1.5).t.Y {
+		t.Errorf("Cubic sample returned %v, expected close to the curve", p)
+	}
+}
+
+func TestAnimator(t *testing.T) {
+	tr := NewTrack(Linear)
+	tr.AddKey(0, NewSQT())
+	end := NewSQT()
+	end.SetTranslation(Vec3{0, 0, 8})
+	tr.AddKey(2, end)
+
+	f := NewFrame()
+	a := &Animator{Track: tr, Frame: f}
+	a.Tick(0.5)
+	a.Tick(0.5)
+	checkPoint(t, "Animator", f.Transform.Translation(), Vec3{0, 0, 4})
+	checkPoint(t, "Animator world", f.World().Translation(), Vec3{0, 0, 4})
+
+	a.Tick(5)
+	checkPoint(t, "Animator after end", f.Transform.Translation(), Vec3{0, 0, 8})
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"math"
+)
+
+// Vec3 represents a three-dimensional vector.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+// Add returns the sum of the vectors a and b.
+func (a Vec3) Add(b Vec3) Vec3 {
+	return Vec3{a.X + b.X, a.Y + b.Y, a.Z + b.Z}
+}
+
+// Sub returns the difference of the vectors a and b.
+func (a Vec3) Sub(b Vec3) Vec3 {
+	return Vec3{a.X - b.X, a.Y - b.Y, a.Z - b.Z}
+}
+
+// Mul returns the vector a scaled by s.
+func (a Vec3) Mul(s float64) Vec3 {
+	return Vec3{a.X * s, a.Y * s, a.Z * s}
+}
+
+// Neg returns the vector pointing in the opposite direction to a.
+func (a Vec3) Neg() Vec3 {
+	return Vec3{-a.X, -a.Y, -a.Z}
+}
+
+// Dot returns the dot product of the vectors a and b.
+func (a Vec3) Dot(b Vec3) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+// Cross returns the cross product of the vectors a and b.
+func (a Vec3) Cross(b Vec3) Vec3 {
+	return Vec3{
+		a.Y*b.Z - a.Z*b.Y,
+		a.Z*b.X - a.X*b.Z,
+		a.X*b.Y - a.Y*b.X,
+	}
+}
+
+// Length returns the length of the vector.
+func (a Vec3) Length() float64 {
+	return math.Sqrt(a.Dot(a))
+}
+
+// Normalise returns the unit vector in the direction of a, or the zero
+// vector if a is zero.
+func (a Vec3) Normalise() Vec3 {
+	l := a.Length()
+	if l == 0 {
+		return Vec3{}
+	}
+	return a.Mul(1 / l)
+}
+
+// Lerp linearly interpolates between the vectors a and b, returning a when
+// t is 0 and b when t is 1.
+func (a Vec3) Lerp(b Vec3, t float64) Vec3 {
+	return a.Add(b.Sub(a).Mul(t))
+}
+
+// Quat represents a quaternion, used for rotations when normalised.
+type Quat struct {
+	X, Y, Z, W float64
+}
+
+// QuatIdentity is the quaternion representing no rotation.
+var QuatIdentity = Quat{0, 0, 0, 1}
+
+// QuatAxisAngle returns the rotation by the angle theta about the axis
+// (which must be normalised).
+func QuatAxisAngle(theta float64, axis Vec3) Quat {
+	sin := math.Sin(theta / 2)
+	return Quat{axis.X * sin, axis.Y * sin, axis.Z * sin, math.Cos(theta / 2)}
+}
+
+// QuatEuler returns the rotation by roll about the z axis, then pitch about
+// the x axis, then yaw about the y axis, all in radians.
+func QuatEuler(yaw, pitch, roll float64) Quat {
+	y := QuatAxisAngle(yaw, Vec3{0, 1, 0})
+	p := QuatAxisAngle(pitch, Vec3{1, 0, 0})
+	r := QuatAxisAngle(roll, Vec3{0, 0, 1})
+	return y.Mul(p).Mul(r)
+}
+
+// QuatFromTo returns the shortest rotation which turns the direction a into
+// the direction b.
+func QuatFromTo(a, b Vec3) Quat {
+	a, b = a.Normalise(), b.Normalise()
+	d := a.Dot(b)
+	if d < -1+1e-12 {
+		// Opposite directions: turn half way round any perpendicular axis
+		axis := Vec3{1, 0, 0}.Cross(a)
+		if axis.Length() < 1e-6 {
+			axis = Vec3{0, 1, 0}.Cross(a)
+		}
+		return QuatAxisAngle(math.Pi, axis.Normalise())
+	}
+	c := a.Cross(b)
+	return Quat{c.X, c.Y, c.Z, 1 + d}.Normalise()
+}
+
+// Mul calculates the Grassman product of the quaternions q and r, which as
+// rotations applies r and then q.
+func (q Quat) Mul(r Quat) Quat {
+	return Quat{
+		q.W*r.X + q.X*r.W + q.Y*r.Z - q.Z*r.Y,
+		q.W*r.Y + q.Y*r.W + q.Z*r.X - q.X*r.Z,
+		q.W*r.Z + q.Z*r.W + q.X*r.Y - q.Y*r.X,
+		q.W*r.W - (q.X*r.X + q.Y*r.Y + q.Z*r.Z),
+	}
+}
+
+// Conjugate returns the conjugate of q, which for a normalised quaternion
+// is the inverse rotation.
+func (q Quat) Conjugate() Quat {
+	return Quat{-q.X, -q.Y, -q.Z, q.W}
+}
+
+// Dot returns the dot product of the quaternions q and r.
+func (q Quat) Dot(r Quat) float64 {
+	return q.X*r.X + q.Y*r.Y + q.Z*r.Z + q.W*r.W
+}
+
+// Normalise returns the unit quaternion in the direction of q.
+func (q Quat) Normalise() Quat {
+	l := math.Sqrt(q.Dot(q))
+	return Quat{q.X / l, q.Y / l, q.Z / l, q.W / l}
+}
+
+// Rotate applies the rotation q (which must be normalised) to the vector v.
+func (q Quat) Rotate(v Vec3) Vec3 {
+	r := q.Mul(Quat{v.X, v.Y, v.Z, 0}.Mul(q.Conjugate()))
+	return Vec3{r.X, r.Y, r.Z}
+}
+
+// AxisAngle returns the angle in radians and the normalised axis of the
+// rotation q. The axis of the identity rotation is the x axis.
+func (q Quat) AxisAngle() (theta float64, axis Vec3) {
+	if q.W < 0 {
+		q = Quat{-q.X, -q.Y, -q.Z, -q.W}
+	}
+	axis = Vec3{q.X, q.Y, q.Z}
+	sin := axis.Length()
+	if sin == 0 {
+		return 0, Vec3{1, 0, 0}
+	}
+	return 2 * math.Atan2(sin, q.W), axis.Mul(1 / sin)
+}
+
+// Euler returns the yaw, pitch and roll of the rotation q, such that
+// QuatEuler(q.Euler()) is the same rotation. Pitch is in the range
+// [-pi/2, pi/2].
+func (q Quat) Euler() (yaw, pitch, roll float64) {
+	sin := 2 * (q.W*q.X - q.Y*q.Z)
+	if sin >= 1 {
+		pitch = math.Pi / 2
+	} else if sin <= -1 {
+		pitch = -math.Pi / 2
+	} else {
+		pitch = math.Asin(sin)
+	}
+	yaw = math.Atan2(2*(q.W*q.Y+q.X*q.Z), 1-2*(q.X*q.X+q.Y*q.Y))
+	roll = math.Atan2(2*(q.W*q.Z+q.X*q.Y), 1-2*(q.X*q.X+q.Z*q.Z))
+	return
+}
+
+// Slerp spherically interpolates between the rotations a and b along the
+// shortest path, returning a when t is 0 and b when t is 1.
+func Slerp(a, b Quat, t float64) Quat {
+	d := a.Dot(b)
+	if d < 0 {
+		b, d = Quat{-b.X, -b.Y, -b.Z, -b.W}, -d
+	}
+	if d > 1-1e-9 {
+		// Too close to divide by the sine, so interpolate linearly
+		return Quat{
+			a.X + (b.X-a.X)*t,
+			a.Y + (b.Y-a.Y)*t,
+			a.Z + (b.Z-a.Z)*t,
+			a.W + (b.W-a.W)*t,
+		}.Normalise()
+	}
+	theta := math.Acos(d)
+	sin := math.Sin(theta)
+	wa := math.Sin((1-t)*theta) / sin
+	wb := math.Sin(t*theta) / sin
+	return Quat{
+		a.X*wa + b.X*wb,
+		a.Y*wa + b.Y*wb,
+		a.Z*wa + b.Z*wb,
+		a.W*wa + b.W*wb,
+	}
+}
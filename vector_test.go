@@ -0,0 +1,124 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVec3(t *testing.T) {
+	a := Vec3{1, 2, 3}
+	b := Vec3{-2, 0, 5}
+
+	if a.Add(b) != (Vec3{-1, 2, 8}) || a.Sub(b) != (Vec3{3, 2, -2}) {
+		t.Error("Add or Sub returned wrong vector")
+	}
+	if a.Mul(2) != (Vec3{2, 4, 6}) || a.Neg() != (Vec3{-1, -2, -3}) {
+		t.Error("Mul or Neg returned wrong vector")
+	}
+	if a.Dot(b) != 13 {
+		t.Errorf("Dot returned %v, expected 13", a.Dot(b))
+	}
+	if c := a.Cross(b); c != (Vec3{10, -11, 4}) || c.Dot(a) != 0 || c.Dot(b) != 0 {
+		t.Errorf("Cross returned %v, expected (10, -11, 4)", c)
+	}
+	if (Vec3{3, 4, 0}).Length() != 5 {
+		t.Error("Length returned wrong result")
+	}
+	checkPoint(t, "Normalise", Vec3{3, 4, 0}.Normalise(), Vec3{0.6, 0.8, 0})
+	if (Vec3{}).Normalise() != (Vec3{}) {
+		t.Error("Normalise did not return zero for zero vector")
+	}
+	if a.Lerp(b, 0.5) != (Vec3{-0.5, 1, 4}) {
+		t.Error("Lerp returned wrong vector")
+	}
+}
+
+func TestQuatAxisAngle(t *testing.T) {
+	q := QuatAxisAngle(math.Pi/2, Vec3{0, 0, 1})
+	checkPoint(t, "Rotate by pi/2 about z", q.Rotate(Vec3{1, 0, 0}), Vec3{0, 1, 0})
+
+	theta, axis := q.AxisAngle()
+	if math.Abs(theta-math.Pi/2) > 1e-14 || axis.Sub(Vec3{0, 0, 1}).Length() > 1e-14 {
+		t.Errorf("AxisAngle returned %v about %v, expected pi/2 about z", theta, axis)
+	}
+
+	axis = Vec3{0.48, 0.6, 0.64}
+	theta, a := QuatAxisAngle(-2, axis).AxisAngle()
+	if math.Abs(theta-2) > 1e-14 || a.Add(axis).Length() > 1e-14 {
+		t.Errorf("AxisAngle returned %v about %v, expected 2 about %v", theta, a, axis.Neg())
+	}
+
+	if theta, _ := QuatIdentity.AxisAngle(); theta != 0 {
+		t.Error("AxisAngle returned non-zero angle for identity")
+	}
+
+	// Mul applies the right hand rotation first
+	r := QuatAxisAngle(math.Pi/2, Vec3{1, 0, 0})
+	checkPoint(t, "Mul", q.Mul(r).Rotate(Vec3{0, 1, 0}), q.Rotate(r.Rotate(Vec3{0, 1, 0})))
+	checkPoint(t, "Conjugate", q.Conjugate().Rotate(q.Rotate(Vec3{1, 2, 3})), Vec3{1, 2, 3})
+}
+
+func TestQuatEuler(t *testing.T) {
+	q := QuatEuler(math.Pi/2, 0, 0)
+	checkPoint(t, "QuatEuler yaw", q.Rotate(Vec3{0, 0, -1}), Vec3{-1, 0, 0})
+	q = QuatEuler(0, math.Pi/2, 0)
+	checkPoint(t, "QuatEuler pitch", q.Rotate(Vec3{0, 0, -1}), Vec3{0, 1, 0})
+
+	angles := [][3]float64{
+		{0, 0, 0},
+		{0.5, -0.3, 1.2},
+		{-2.5, 1.4, -3},
+		{3, -1.5, 0.1},
+	}
+	for _, a := range angles {
+		yaw, pitch, roll := QuatEuler(a[0], a[1], a[2]).Euler()
+		if math.Abs(yaw-a[0]) > 1e-12 || math.Abs(pitch-a[1]) > 1e-12 || math.Abs(roll-a[2]) > 1e-12 {
+			t.Errorf("Euler returned (%v, %v, %v), expected %v", yaw, pitch, roll, a)
+		}
+	}
+}
+
+func TestQuatFromTo(t *testing.T) {
+	pairs := [][2]Vec3{
+		{{1, 0, 0}, {0, 1, 0}},
+		{{1, 2, 3}, {-3, 0.5, 2}},
+		{{0, 0, 1}, {0, 0, 1}},
+		{{1, 0, 0}, {-1, 0, 0}},
+		{{0, 2, 0}, {0, -1, 0}},
+	}
+	for _, p := range pairs {
+		q := QuatFromTo(p[0], p[1])
+		checkPoint(t, "QuatFromTo", q.Rotate(p[0].Normalise()), p[1].Normalise())
+		if math.Abs(q.Dot(q)-1) > 1e-14 {
+			t.Error("QuatFromTo returned non-normalised quaternion")
+		}
+	}
+}
+
+func TestSlerp(t *testing.T) {
+	a := QuatAxisAngle(0.2, Vec3{0, 1, 0})
+	b := QuatAxisAngle(1.8, Vec3{0, 1, 0})
+
+	if Slerp(a, b, 0) != a {
+		t.Error("Slerp did not return a at 0")
+	}
+	for _, c := range []struct{ t, theta float64 }{{0.5, 1}, {0.25, 0.6}, {1, 1.8}} {
+		theta, axis := Slerp(a, b, c.t).AxisAngle()
+		if math.Abs(theta-c.theta) > 1e-12 || axis.Sub(Vec3{0, 1, 0}).Length() > 1e-12 {
+			t.Errorf("Slerp returned %v about %v at %v, expected %v", theta, axis, c.t, c.theta)
+		}
+	}
+
+	// The shortest path between opposite representations of nearby
+	// rotations does not go the long way round
+	nb := b
+	nb.X, nb.Y, nb.Z, nb.W = -b.X, -b.Y, -b.Z, -b.W
+	theta, _ := Slerp(a, nb, 0.5).AxisAngle()
+	if math.Abs(theta-1) > 1e-12 {
+		t.Errorf("Slerp took the long path, returning angle %v", theta)
+	}
+
+	if q := Slerp(a, a, 0.3); math.Abs(q.Dot(a)-1) > 1e-12 {
+		t.Error("Slerp between equal rotations did not return the same rotation")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"github.com/3SillyHats/DarkLogic/generate"
+)
+
+// World fills a Frame from a generate.Generator lazily, one chunk at a
+// time.
+type World struct {
+	Frame     *Frame
+	Generator generate.Generator
+	Ores      *OrePass // seeds ore into each chunk after generating it, if not nil
+	generated map[pos]bool
+
+	// The last call to GenerateAround, so that it can skip the scan while
+	// the centre stays in the same chunk
+	around       bool
+	centre       pos
+	aroundRadius int
+}
+
+// NewWorld creates a world which generates blocks of f with g.
+func NewWorld(f *Frame, g generate.Generator) *World {
+	return &World{Frame: f, Generator: g, generated: make(map[pos]bool)}
+}
+
+// Generate fills the chunk at chunk coordinates (cx, cy, cz) with blocks
+// from the generator, unless it has already been generated, and reports
+// whether it did. Blocks are written through SetBlock, so mass and chunk
+// versions stay up to date.
+func (w *World) Generate(cx, cy, cz int) bool {
+	p := pos{cx, cy, cz}
+	if w.generated[p] {
+		return false
+	}
+	w.generated[p] = true
+	for i := 0; i < ncx; i++ {
+		for j := 0; j < ncy; j++ {
+			for k := 0; k < ncz; k++ {
+				x, y, z := cx*ncx+i, cy*ncy+j, cz*ncz+k
+				if id := w.Generator.Block(x, y, z); id != 0 {
+					w.Frame.SetBlock(x, y, z, Block{Id: id})
+				}
+			}
+		}
+	}
+	if w.Ores != nil {
+		w.Ores.Place(w.Frame, cx, cy, cz)
+	}
+	return true
+}
+
+// GenerateAround generates every chunk within radius chunks of the chunk
+// containing the voxel (x, y, z), and returns how many were new. It does
+// nothing if called again with the same chunk and radius, so it is cheap
+// to call every frame.
+func (w *World) GenerateAround(x, y, z, radius int) (n int) {
+	c, _, _, _ := locate(x, y, z)
+	if w.around && c == w.centre && radius == w.aroundRadius {
+		return 0
+	}
+	w.around, w.centre, w.aroundRadius = true, c, radius
+	for i := -radius; i <= radius; i++ {
+		for j := -radius; j <= radius; j++ {
+			for k := -radius; k <= radius; k++ {
+				if w.Generate(c.x+i, c.y+j, c.z+k) {
+					n++
+				}
+			}
+		}
+	}
+	return
+}
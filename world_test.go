@@ -0,0 +1,86 @@
+package main
+
+import (
+	"hash/fnv"
+	"testing"
+
+	"github.com/3SillyHats/DarkLogic/generate"
+)
+
+// chunkDigest returns a hash of the blocks in the chunk at p, so that
+// generated chunks can be compared with snapshots.
+func chunkDigest(f *Frame, p pos) uint64 {
+	h := fnv.New64a()
+	c := f.chunks[p]
+	for i := range c {
+		for j := range c[i] {
+			for _, b := range c[i][j] {
+				h.Write([]byte{byte(b.Id), byte(b.Id >> 8), byte(b.Data), byte(b.Data >> 8)})
+			}
+		}
+	}
+	return h.Sum64()
+}
+
+func TestWorldGenerate(t *testing.T) {
+	w := NewWorld(NewFrame(), generate.NewTerrain(42))
+	if n := w.GenerateAround(0, 0, 0, 1); n != 27 {
+		t.Errorf("GenerateAround generated %d chunks, expected 27", n)
+	}
+	if w.Generate(1, 1, 1) {
+		t.Error("Generate generated chunk twice")
+	}
+
+	// Moving within the same chunk does not scan again
+	delete(w.generated, pos{1, 1, 1})
+	if n := w.GenerateAround(15, 15, 15, 1); n != 0 {
+		t.Errorf("GenerateAround generated %d chunks from the same chunk, expected 0", n)
+	}
+	w.generated[pos{1, 1, 1}] = true
+
+	if n := w.GenerateAround(16, 0, 0, 1); n != 9 {
+		t.Errorf("GenerateAround generated %d new chunks, expected 9", n)
+	}
+
+	// Generating in a different order gives the same blocks
+	v := NewWorld(NewFrame(), generate.NewTerrain(42))
+	for i := 2; i >= -1; i-- {
+		for j := 1; j >= -1; j-- {
+			for k := -1; k <= 1; k++ {
+				v.Generate(i, j, k)
+			}
+		}
+	}
+	if len(v.Frame.chunks) != len(w.Frame.chunks) {
+		t.Fatal("Generate in a different order produced different chunks")
+	}
+	for p := range w.Frame.chunks {
+		if chunkDigest(v.Frame, p) != chunkDigest(w.Frame, p) {
+			t.Error("Generate in a different order produced different blocks in chunk", p)
+		}
+	}
+	if v.Frame.Mass() != w.Frame.Mass() {
+		t.Error("Generate did not update mass through SetBlock")
+	}
+}
+
+func TestGeneratorSnapshots(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		gen    generate.Generator
+		p      pos
+		digest uint64
+	}{
+		{"terrain", generate.NewTerrain(7), pos{0, 0, 0}, 0xf1d7b29ebbcee884},
+		{"terrain", generate.NewTerrain(7), pos{-2, -1, 3}, 0xc832c8ef8b5686d5},
+		{"asteroid", generate.NewAsteroid(7, 20), pos{0, 0, 0}, 0x4e534f072ef9eb5},
+		{"asteroid", generate.NewAsteroid(7, 20), pos{-1, 0, -1}, 0xe93e782af8da95f6},
+		{"caves", generate.NewCaves(7, generate.NewTerrain(7)), pos{0, -1, 0}, 0x6c28ba93688927f5},
+	} {
+		w := NewWorld(NewFrame(), c.gen)
+		w.Generate(c.p.x, c.p.y, c.p.z)
+		if d := chunkDigest(w.Frame, c.p); d != c.digest {
+			t.Errorf("%s chunk %v has digest %#x, expected %#x", c.name, c.p, d, c.digest)
+		}
+	}
+}